@@ -0,0 +1,169 @@
+// Команда mockinfo запускает заглушку внешнего API информации о песнях
+// для локальной разработки и интеграционных тестов.
+//
+// Сервер отвечает на запросы GET /info?group=&song= данными из JSON- и CSV-файлов
+// каталога фикстур (формат как у источника DETAILS_PROVIDER=file) и может имитировать
+// задержку, ошибки и отсутствие песен:
+//
+//	go run ./cmd/mockinfo -fixtures ./cmd/mockinfo/fixtures -latency 200ms -error-rate 0.1
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"music_library/internal/models"
+	"music_library/internal/provider"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config описывает настройки заглушки
+type config struct {
+	addr         string
+	fixtures     string
+	latency      time.Duration
+	jitter       time.Duration
+	errorRate    float64
+	errorStatus  int
+	notFoundRate float64
+	retryAfter   int
+}
+
+// server отвечает на запросы /info по данным фикстур
+type server struct {
+	cfg     config
+	catalog []*provider.FileProvider
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.addr, "addr", ":8081", "адрес сервера")
+	flag.StringVar(&cfg.fixtures, "fixtures", "./cmd/mockinfo/fixtures", "каталог JSON- и CSV-файлов с информацией о песнях")
+	flag.DurationVar(&cfg.latency, "latency", 0, "задержка каждого ответа")
+	flag.DurationVar(&cfg.jitter, "jitter", 0, "максимальная случайная добавка к задержке")
+	flag.Float64Var(&cfg.errorRate, "error-rate", 0, "доля запросов, завершающихся ошибкой (0..1)")
+	flag.IntVar(&cfg.errorStatus, "error-status", http.StatusInternalServerError, "статус ответа при имитации ошибки")
+	flag.Float64Var(&cfg.notFoundRate, "not-found-rate", 0, "доля запросов, на которые отвечается 404 для известных песен (0..1)")
+	flag.IntVar(&cfg.retryAfter, "retry-after", 0, "значение заголовка Retry-After в секундах при имитации ошибки, 0 - без заголовка")
+	flag.Parse()
+
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run загружает фикстуры и запускает сервер
+func run(cfg config) error {
+	for name, rate := range map[string]float64{"error-rate": cfg.errorRate, "not-found-rate": cfg.notFoundRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("неверное значение %s: %v, ожидается число от 0 до 1", name, rate)
+		}
+	}
+
+	catalog, err := loadFixtures(cfg.fixtures)
+	if err != nil {
+		return err
+	}
+
+	s := &server{cfg: cfg, catalog: catalog}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", s.info)
+
+	log.Printf("Заглушка внешнего API запущена на %s, файлов фикстур: %d", cfg.addr, len(catalog))
+	return http.ListenAndServe(cfg.addr, mux)
+}
+
+// loadFixtures загружает все JSON- и CSV-файлы каталога
+func loadFixtures(dir string) ([]*provider.FileProvider, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога фикстур: %w", err)
+	}
+
+	var catalog []*provider.FileProvider
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".json" && ext != ".csv") {
+			continue
+		}
+
+		p, err := provider.NewFileProvider(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("фикстура %s: %w", file.Name(), err)
+		}
+		catalog = append(catalog, p)
+	}
+	return catalog, nil
+}
+
+// info обрабатывает запрос GET /info?group=&song= по контракту внешнего API
+func (s *server) info(w http.ResponseWriter, r *http.Request) {
+	group, song := r.URL.Query().Get("group"), r.URL.Query().Get("song")
+	if group == "" || song == "" {
+		http.Error(w, "параметры group и song обязательны", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.wait(r.Context()); err != nil {
+		return
+	}
+
+	if rand.Float64() < s.cfg.errorRate {
+		log.Printf("Имитация ошибки %d: %s - %s", s.cfg.errorStatus, group, song)
+		if s.cfg.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(s.cfg.retryAfter))
+		}
+		http.Error(w, "имитация ошибки", s.cfg.errorStatus)
+		return
+	}
+
+	details, err := s.lookup(r.Context(), group, song)
+	if errors.Is(err, models.ErrNotFound) || (err == nil && rand.Float64() < s.cfg.notFoundRate) {
+		log.Printf("Песня не найдена: %s - %s", group, song)
+		http.Error(w, "песня не найдена", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// lookup ищет песню в файлах фикстур в порядке их имен
+func (s *server) lookup(ctx context.Context, group, song string) (models.SongDetails, error) {
+	for _, p := range s.catalog {
+		details, err := p.GetSongDetails(ctx, group, song)
+		if err == nil {
+			return details, nil
+		}
+	}
+	return models.SongDetails{}, fmt.Errorf("песня %s - %s: %w", group, song, models.ErrNotFound)
+}
+
+// wait имитирует задержку ответа
+func (s *server) wait(ctx context.Context) error {
+	delay := s.cfg.latency
+	if s.cfg.jitter > 0 {
+		delay += rand.N(s.cfg.jitter)
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
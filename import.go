@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"os"
+)
+
+// runImport выполняет команду импорта песен из файла:
+//
+//	music_library import [-format csv|json|ndjson] [-mode upsert|insert] [-dry-run] <файл>
+//
+// Итог импорта выводится в stdout в формате JSON. Возвращает код завершения:
+// 1, если импорт не удался или в файле есть ошибочные строки.
+func runImport(musicService service.MusicService, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "формат файла: csv, json или ndjson, по умолчанию определяется по расширению")
+	mode := fs.String("mode", models.ImportModeUpsert, "режим: upsert - добавлять и обновлять песни, insert - только добавлять новые")
+	dryRun := fs.Bool("dry-run", false, "проверить файл без сохранения")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "использование: music_library import [-format csv|json|ndjson] [-mode upsert|insert] [-dry-run] <файл>")
+		return 2
+	}
+	opts := models.ImportOptions{DryRun: *dryRun}
+	if err := opts.SetMode(*mode); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	path := fs.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Ошибка открытия файла импорта: %v", err)
+		return 1
+	}
+	defer file.Close()
+
+	if *format == "" {
+		*format = importer.FormatFromName(path)
+	}
+	rows, err := importer.NewReader(file, *format)
+	if err != nil {
+		log.Printf("Ошибка чтения файла импорта: %v", err)
+		return 1
+	}
+
+	report, err := musicService.ImportSongs(context.Background(), rows, opts)
+	return printImportReport(report, err)
+}
+
+// printImportReport выводит итог импорта в stdout и возвращает код завершения
+func printImportReport(report models.ImportReport, err error) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if err != nil {
+		log.Printf("Ошибка импорта: %v", err)
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
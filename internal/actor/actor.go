@@ -0,0 +1,47 @@
+// Package actor хранит в контексте запроса сведения о том, кто выполняет изменение.
+package actor
+
+import "context"
+
+// Anonymous - имя инициатора, если он не был указан
+const Anonymous = "anonymous"
+
+// ctxKey определяет тип ключа для контекста
+type ctxKey struct{}
+
+// requestKey определяет тип ключа для сведений о запросе
+type requestKey struct{}
+
+// Request - сведения о запросе, в рамках которого выполняется изменение
+type Request struct {
+	// ID - идентификатор запроса из middleware.RequestID
+	ID string `json:"request_id,omitempty"`
+	// IP - адрес клиента с учетом middleware.RealIP
+	IP string `json:"ip,omitempty"`
+}
+
+// WithName возвращает контекст с именем инициатора изменения
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext возвращает имя инициатора изменения из контекста
+func FromContext(ctx context.Context) string {
+	name, ok := ctx.Value(ctxKey{}).(string)
+	if !ok || name == "" {
+		return Anonymous
+	}
+	return name
+}
+
+// WithRequest возвращает контекст со сведениями о запросе
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFromContext возвращает сведения о запросе из контекста. Для изменений
+// вне запроса (например, фоновое получение информации о песнях) они пустые.
+func RequestFromContext(ctx context.Context) Request {
+	req, _ := ctx.Value(requestKey{}).(Request)
+	return req
+}
@@ -0,0 +1,101 @@
+// Package anchor привязывает аннотации к диапазону символов текста куплета
+// и переносит привязки при изменении текста.
+package anchor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Anchor - привязка к диапазону символов [Start, End) текста куплета с номером Verse.
+// Позиции считаются в символах (рунах), Quote - текст диапазона на момент привязки.
+type Anchor struct {
+	Verse int
+	Start int
+	End   int
+	Quote string
+}
+
+// Resolve проверяет диапазон символов в тексте куплета и возвращает привязку с его текстом.
+// texts - тексты куплетов песни по номерам.
+func Resolve(texts map[int]string, verse, start, end int) (Anchor, error) {
+	text, ok := texts[verse]
+	if !ok {
+		return Anchor{}, fmt.Errorf("у песни нет куплета %d", verse)
+	}
+	runes := []rune(text)
+	if start < 0 || end <= start || end > len(runes) {
+		return Anchor{}, fmt.Errorf("диапазон [%d, %d) вне текста куплета %d длиной %d символов", start, end, verse, len(runes))
+	}
+	return Anchor{Verse: verse, Start: start, End: end, Quote: string(runes[start:end])}, nil
+}
+
+// Reanchor находит положение привязки в новых текстах куплетов. Если текст диапазона не изменился,
+// привязка остается на месте. Иначе цитата ищется сначала в том же куплете, затем в ближайших
+// по номеру куплетах; из нескольких вхождений в куплете выбирается ближайшее к прежней позиции.
+// Если цитата не найдена, возвращается прежняя привязка и false - аннотация осиротела.
+func Reanchor(a Anchor, texts map[int]string) (Anchor, bool) {
+	quote := []rune(a.Quote)
+	if len(quote) == 0 {
+		return a, false
+	}
+
+	if text, ok := texts[a.Verse]; ok {
+		runes := []rune(text)
+		if a.End <= len(runes) && a.Start >= 0 && string(runes[a.Start:a.End]) == a.Quote {
+			return a, true
+		}
+	}
+
+	verses := make([]int, 0, len(texts))
+	for verse := range texts {
+		verses = append(verses, verse)
+	}
+	sort.Slice(verses, func(i, j int) bool {
+		di, dj := distance(verses[i], a.Verse), distance(verses[j], a.Verse)
+		if di != dj {
+			return di < dj
+		}
+		return verses[i] < verses[j]
+	})
+
+	for _, verse := range verses {
+		start, ok := nearest([]rune(texts[verse]), quote, a.Start)
+		if ok {
+			return Anchor{Verse: verse, Start: start, End: start + len(quote), Quote: a.Quote}, true
+		}
+	}
+	return a, false
+}
+
+// nearest возвращает позицию вхождения needle в text, ближайшую к pos
+func nearest(text, needle []rune, pos int) (int, bool) {
+	best, found := 0, false
+	for i := 0; i+len(needle) <= len(text); i++ {
+		if !equalAt(text, needle, i) {
+			continue
+		}
+		if !found || distance(i, pos) < distance(best, pos) {
+			best, found = i, true
+		}
+	}
+	return best, found
+}
+
+// equalAt проверяет, что needle входит в text с позиции i
+func equalAt(text, needle []rune, i int) bool {
+	for j, r := range needle {
+		if text[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// distance возвращает расстояние между двумя числами
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,237 @@
+// Package auth выдает и проверяет токены доступа JWT и токены обновления,
+// хэширует пароли и хранит в контексте запроса вошедшего пользователя.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// issuer - издатель токенов доступа (поле iss)
+const issuer = "music_library"
+
+// MinPasswordLength - минимальная длина пароля пользователя
+const MinPasswordLength = 8
+
+// Config описывает настройки аутентификации
+type Config struct {
+	// Secret - ключ подписи токенов доступа (HS256)
+	Secret []byte
+	// AccessTTL - время жизни токена доступа
+	AccessTTL time.Duration
+	// RefreshTTL - время жизни токена обновления
+	RefreshTTL time.Duration
+	// Required - запрещает запросы без токена доступа
+	Required bool
+	// DevMode - режим разработки: без AUTH_JWT_SECRET используется случайный ключ подписи
+	DevMode bool
+}
+
+// ConfigFromEnv читает настройки аутентификации из переменных окружения.
+// Для незаданных настроек используются значения по умолчанию. Ключ подписи AUTH_JWT_SECRET
+// обязателен. Только в режиме разработки (AUTH_DEV_MODE=true) вместо незаданного ключа создается
+// случайный: выданные токены перестанут действовать после перезапуска.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Secret:     []byte(os.Getenv("AUTH_JWT_SECRET")),
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+		Required:   true,
+	}
+
+	flags := map[string]*bool{
+		"AUTH_REQUIRED": &cfg.Required,
+		"AUTH_DEV_MODE": &cfg.DevMode,
+	}
+	for name, dst := range flags {
+		if value := os.Getenv(name); value != "" {
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("неверное значение %s: %q", name, value)
+			}
+			*dst = flag
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"AUTH_ACCESS_TTL":  &cfg.AccessTTL,
+		"AUTH_REFRESH_TTL": &cfg.RefreshTTL,
+	}
+	for name, dst := range durations {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return Config{}, fmt.Errorf("неверное значение %s: %q", name, value)
+			}
+			*dst = d
+		}
+	}
+
+	if len(cfg.Secret) == 0 {
+		if !cfg.DevMode {
+			return Config{}, fmt.Errorf("AUTH_JWT_SECRET не задан, случайный ключ подписи допускается только при AUTH_DEV_MODE=true")
+		}
+		log.Printf("AUTH_JWT_SECRET не задан, в режиме разработки используется случайный ключ подписи токенов")
+		cfg.Secret = make([]byte, 32)
+		if _, err := rand.Read(cfg.Secret); err != nil {
+			return Config{}, fmt.Errorf("ошибка создания ключа подписи: %w", err)
+		}
+	} else if len(cfg.Secret) < 32 {
+		return Config{}, fmt.Errorf("AUTH_JWT_SECRET должен содержать не меньше 32 байт")
+	}
+
+	return cfg, nil
+}
+
+// Claims - содержимое токена доступа. Subject - ID пользователя.
+type Claims struct {
+	Username string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// Tokens выдает и проверяет токены доступа
+type Tokens struct {
+	cfg Config
+}
+
+// NewTokens создает Tokens с ключом подписи и временем жизни из cfg
+func NewTokens(cfg Config) *Tokens {
+	return &Tokens{cfg: cfg}
+}
+
+// IssueAccess выдает подписанный токен доступа пользователя и возвращает время его истечения
+func (t *Tokens) IssueAccess(user models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.cfg.AccessTTL)
+	claims := Claims{
+		Username: user.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.cfg.Secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("ошибка подписи токена доступа: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseAccess проверяет подпись и срок действия токена доступа и возвращает ID пользователя
+func (t *Tokens) ParseAccess(token string) (int, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.cfg.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("%w: недействительный токен доступа: %v", models.ErrUnauthorized, err)
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, fmt.Errorf("%w: неверный пользователь в токене доступа", models.ErrUnauthorized)
+	}
+	return id, nil
+}
+
+// RefreshExpiry возвращает время истечения нового токена обновления
+func (t *Tokens) RefreshExpiry() time.Time {
+	return time.Now().Add(t.cfg.RefreshTTL)
+}
+
+// NewRefreshToken создает случайный токен обновления. В базе данных хранится только его хэш.
+func NewRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка создания токена обновления: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken возвращает хэш токена для хранения в базе данных. Токен случайный и длинный,
+// поэтому медленный хэш, как для паролей, не нужен.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashPassword возвращает bcrypt-хэш пароля
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", fmt.Errorf("%w: пароль длиннее 72 байт", models.ErrInvalidInput)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка хэширования пароля: %w", err)
+	}
+	return string(hash), nil
+}
+
+// dummyHash сравнивается с паролем, если пользователь не найден, чтобы время ответа
+// не выдавало существование имени
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("music_library"), bcrypt.DefaultCost)
+
+// CheckPassword сравнивает пароль с хэшем. Пустой хэш означает несуществующего пользователя.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// ctxKey определяет тип ключа для контекста
+type ctxKey struct{}
+
+// WithUser возвращает контекст с вошедшим пользователем
+func WithUser(ctx context.Context, user models.User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, user)
+}
+
+// UserFromContext возвращает вошедшего пользователя из контекста
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(ctxKey{}).(models.User)
+	return user, ok
+}
+
+// apiKeyPrefix отличает ключи API сервиса от других секретов, например при поиске утечек
+const apiKeyPrefix = "ml_"
+
+// NewAPIKey создает случайный ключ API и возвращает его вместе с началом для опознания ключа в списке
+func NewAPIKey() (string, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("ошибка создания ключа API: %w", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
+
+// apiKeyCtxKey определяет тип ключа контекста для ключа API
+type apiKeyCtxKey struct{}
+
+// WithAPIKey возвращает контекст с ключом API, которым выполнен запрос
+func WithAPIKey(ctx context.Context, key models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyCtxKey{}, key)
+}
+
+// APIKeyFromContext возвращает ключ API, которым выполнен запрос
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(models.APIKey)
+	return key, ok
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/anchor"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// annotationColumns - столбцы таблицы annotations для annotationRow
+const annotationColumns = `id, song_id, parent_id, verse_number, start_offset, end_offset, quote, orphaned, body, author, created_at, updated_at`
+
+// annotationRow - строка таблицы annotations, у ответов поля привязки пустые
+type annotationRow struct {
+	ID          int            `db:"id"`
+	SongID      int            `db:"song_id"`
+	ParentID    sql.NullInt64  `db:"parent_id"`
+	VerseNumber sql.NullInt64  `db:"verse_number"`
+	Start       sql.NullInt64  `db:"start_offset"`
+	End         sql.NullInt64  `db:"end_offset"`
+	Quote       sql.NullString `db:"quote"`
+	Orphaned    bool           `db:"orphaned"`
+	Body        string         `db:"body"`
+	Author      string         `db:"author"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+// toModel преобразует строку таблицы в модель аннотации
+func (row annotationRow) toModel() models.Annotation {
+	annotation := models.Annotation{
+		ID:        row.ID,
+		SongID:    row.SongID,
+		Orphaned:  row.Orphaned,
+		Body:      row.Body,
+		Author:    row.Author,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.ParentID.Valid {
+		parentID := int(row.ParentID.Int64)
+		annotation.ParentID = &parentID
+	}
+	if row.VerseNumber.Valid {
+		annotation.Anchor = &models.AnnotationAnchor{
+			VerseNumber: int(row.VerseNumber.Int64),
+			Start:       int(row.Start.Int64),
+			End:         int(row.End.Int64),
+			Quote:       row.Quote.String,
+		}
+	}
+	return annotation
+}
+
+// toAnchor возвращает привязку аннотации верхнего уровня
+func (row annotationRow) toAnchor() anchor.Anchor {
+	return anchor.Anchor{
+		Verse: int(row.VerseNumber.Int64),
+		Start: int(row.Start.Int64),
+		End:   int(row.End.Int64),
+		Quote: row.Quote.String,
+	}
+}
+
+// CreateAnnotation добавляет аннотацию к диапазону символов куплета песни.
+// Диапазон проверяется по текущему тексту куплета, его текст сохраняется для переноса привязки.
+func (r *PostgresRepository) CreateAnnotation(ctx context.Context, songID int, req models.AnnotationRequest) (models.Annotation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	snapshot, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return models.Annotation{}, err
+	}
+	a, err := anchor.Resolve(versesByNumber(snapshot.Verses), req.VerseNumber, req.Start, req.End)
+	if err != nil {
+		return models.Annotation{}, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+
+	query := `
+		INSERT INTO annotations (song_id, verse_number, start_offset, end_offset, quote, body, author)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + annotationColumns
+	var row annotationRow
+	err = tx.GetContext(ctx, &row, query, songID, a.Verse, a.Start, a.End, a.Quote, req.Body, actor.FromContext(ctx))
+	if err != nil {
+		log.Printf("Ошибка добавления аннотации: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка добавления аннотации: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Аннотация добавлена, ID: %d, SongID: %d", row.ID, songID)
+	return row.toModel(), nil
+}
+
+// CreateReply добавляет ответ на аннотацию или на другой ответ той же песни
+func (r *PostgresRepository) CreateReply(ctx context.Context, songID, parentID int, body string) (models.Annotation, error) {
+	query := `
+		INSERT INTO annotations (song_id, parent_id, body, author)
+		SELECT song_id, id, $3, $4
+		FROM annotations
+		WHERE id = $2 AND song_id = $1
+		RETURNING ` + annotationColumns
+	var row annotationRow
+	err := r.db.GetContext(ctx, &row, query, songID, parentID, body, actor.FromContext(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Annotation{}, fmt.Errorf("аннотация %d песни %d: %w", parentID, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка добавления ответа на аннотацию: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка добавления ответа на аннотацию: %w", err)
+	}
+
+	log.Printf("Ответ на аннотацию %d добавлен, ID: %d", parentID, row.ID)
+	return row.toModel(), nil
+}
+
+// GetAnnotations получает аннотации песни и ответы на них в порядке добавления
+func (r *PostgresRepository) GetAnnotations(ctx context.Context, songID int) ([]models.Annotation, error) {
+	query := `SELECT ` + annotationColumns + ` FROM annotations WHERE song_id = $1 ORDER BY id`
+
+	var rows []annotationRow
+	if err := r.db.SelectContext(ctx, &rows, query, songID); err != nil {
+		log.Printf("Ошибка получения аннотаций: %v", err)
+		return nil, fmt.Errorf("ошибка получения аннотаций: %w", err)
+	}
+
+	annotations := make([]models.Annotation, 0, len(rows))
+	for _, row := range rows {
+		annotations = append(annotations, row.toModel())
+	}
+	return annotations, nil
+}
+
+// UpdateAnnotation изменяет текст аннотации. Если в запросе указан куплет, аннотация
+// привязывается к новому диапазону символов и перестает считаться осиротевшей.
+func (r *PostgresRepository) UpdateAnnotation(ctx context.Context, songID, id int, upd models.AnnotationUpdate) (models.Annotation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current annotationRow
+	query := `SELECT ` + annotationColumns + ` FROM annotations WHERE id = $1 AND song_id = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &current, query, id, songID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Annotation{}, fmt.Errorf("аннотация %d песни %d: %w", id, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения аннотации: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка получения аннотации: %w", err)
+	}
+
+	var row annotationRow
+	if upd.VerseNumber == nil {
+		query = `UPDATE annotations SET body = $2, updated_at = now() WHERE id = $1 RETURNING ` + annotationColumns
+		err = tx.GetContext(ctx, &row, query, id, upd.Body)
+	} else {
+		if current.ParentID.Valid {
+			return models.Annotation{}, fmt.Errorf("%w: ответ на аннотацию нельзя привязать к тексту", models.ErrInvalidInput)
+		}
+		var snapshot models.SongSnapshot
+		if snapshot, err = loadSnapshot(ctx, tx, songID); err != nil {
+			return models.Annotation{}, err
+		}
+		var a anchor.Anchor
+		if a, err = anchor.Resolve(versesByNumber(snapshot.Verses), *upd.VerseNumber, upd.Start, upd.End); err != nil {
+			return models.Annotation{}, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+		}
+		query = `
+			UPDATE annotations
+			SET body = $2, verse_number = $3, start_offset = $4, end_offset = $5, quote = $6,
+				orphaned = false, updated_at = now()
+			WHERE id = $1
+			RETURNING ` + annotationColumns
+		err = tx.GetContext(ctx, &row, query, id, upd.Body, a.Verse, a.Start, a.End, a.Quote)
+	}
+	if err != nil {
+		log.Printf("Ошибка изменения аннотации: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка изменения аннотации: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return models.Annotation{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return row.toModel(), nil
+}
+
+// DeleteAnnotation удаляет аннотацию вместе с ответами на нее
+func (r *PostgresRepository) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM annotations WHERE id = $1 AND song_id = $2`, id, songID)
+	if err != nil {
+		log.Printf("Ошибка удаления аннотации: %v", err)
+		return fmt.Errorf("ошибка удаления аннотации: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("аннотация %d песни %d: %w", id, songID, models.ErrNotFound)
+	}
+
+	log.Printf("Аннотация удалена, ID: %d", id)
+	return nil
+}
+
+// reanchorAnnotations переносит привязки аннотаций песни после изменения ее куплетов.
+// Аннотация, фрагмент которой не найден в новом тексте, помечается осиротевшей и сохраняет
+// прежнюю привязку; осиротевшая аннотация снова привязывается, если фрагмент появился в тексте.
+func reanchorAnnotations(ctx context.Context, tx *sqlx.Tx, songID int, verses []models.Verse) error {
+	query := `SELECT ` + annotationColumns + ` FROM annotations WHERE song_id = $1 AND parent_id IS NULL FOR UPDATE`
+	var rows []annotationRow
+	if err := tx.SelectContext(ctx, &rows, query, songID); err != nil {
+		log.Printf("Ошибка получения аннотаций для переноса: %v", err)
+		return fmt.Errorf("ошибка получения аннотаций для переноса: %w", err)
+	}
+
+	texts := versesByNumber(verses)
+	query = `
+		UPDATE annotations
+		SET verse_number = $2, start_offset = $3, end_offset = $4, orphaned = $5
+		WHERE id = $1
+	`
+	for _, row := range rows {
+		old := row.toAnchor()
+		moved, ok := anchor.Reanchor(old, texts)
+		if moved == old && ok != row.Orphaned {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, query, row.ID, moved.Verse, moved.Start, moved.End, !ok); err != nil {
+			log.Printf("Ошибка переноса аннотации: %v", err)
+			return fmt.Errorf("ошибка переноса аннотации: %w", err)
+		}
+		if !ok && !row.Orphaned {
+			log.Printf("Фрагмент аннотации %d не найден в тексте песни %d", row.ID, songID)
+		}
+	}
+	return nil
+}
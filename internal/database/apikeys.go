@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns - столбцы ключа API без хэша
+const apiKeyColumns = `id, name, prefix, scopes, expires_at, last_used_at, created_by, created_at, revoked_at`
+
+// apiKeyRow - строка таблицы api_keys, области действия хранятся в массиве
+type apiKeyRow struct {
+	ID         int            `db:"id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	CreatedBy  *int           `db:"created_by"`
+	CreatedAt  time.Time      `db:"created_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+}
+
+// toModel преобразует строку таблицы в модель ключа API
+func (row apiKeyRow) toModel() models.APIKey {
+	return models.APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Scopes:     []string(row.Scopes),
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt,
+		RevokedAt:  row.RevokedAt,
+	}
+}
+
+// CreateAPIKey сохраняет ключ API по его хэшу
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns
+
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, query, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), key.ExpiresAt, key.CreatedBy)
+	if err != nil {
+		log.Printf("Ошибка создания ключа API: %v", err)
+		return models.APIKey{}, fmt.Errorf("ошибка создания ключа API: %w", err)
+	}
+
+	log.Printf("Ключ API создан, ID: %d", row.ID)
+	return row.toModel(), nil
+}
+
+// GetAPIKeys получает все ключи API, включая отозванные
+func (r *PostgresRepository) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	var rows []apiKeyRow
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		log.Printf("Ошибка получения ключей API: %v", err)
+		return nil, fmt.Errorf("ошибка получения ключей API: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toModel())
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ API
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Printf("Ошибка отзыва ключа API: %v", err)
+		return fmt.Errorf("ошибка отзыва ключа API: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("действующий ключ API %d: %w", id, models.ErrNotFound)
+	}
+
+	log.Printf("Ключ API отозван, ID: %d", id)
+	return nil
+}
+
+// lastUsedPrecision - точность времени последнего использования ключа. Время обновляется
+// не чаще этого интервала, чтобы каждый запрос не приводил к записи в базу данных.
+const lastUsedPrecision = time.Minute
+
+// UseAPIKey находит действующий ключ API по хэшу и отмечает время его использования
+func (r *PostgresRepository) UseAPIKey(ctx context.Context, keyHash string) (models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, query, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, fmt.Errorf("%w: неизвестный ключ API", models.ErrUnauthorized)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения ключа API: %v", err)
+		return models.APIKey{}, fmt.Errorf("ошибка получения ключа API: %w", err)
+	}
+
+	now := time.Now()
+	if row.RevokedAt != nil {
+		return models.APIKey{}, fmt.Errorf("%w: ключ API отозван", models.ErrUnauthorized)
+	}
+	if row.ExpiresAt != nil && now.After(*row.ExpiresAt) {
+		return models.APIKey{}, fmt.Errorf("%w: срок действия ключа API истек", models.ErrUnauthorized)
+	}
+
+	if row.LastUsedAt == nil || now.Sub(*row.LastUsedAt) >= lastUsedPrecision {
+		if _, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = now() WHERE id = $1`, row.ID); err != nil {
+			// Ошибка отметки не мешает запросу
+			log.Printf("Ошибка обновления времени использования ключа API: %v", err)
+		} else {
+			row.LastUsedAt = &now
+		}
+	}
+
+	return row.toModel(), nil
+}
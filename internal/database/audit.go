@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditRow - строка таблицы audit_log, состояния до и после изменения хранятся в JSONB
+type auditRow struct {
+	ID        int64     `db:"id"`
+	Entity    string    `db:"entity"`
+	EntityID  int       `db:"entity_id"`
+	Action    string    `db:"action"`
+	Actor     string    `db:"actor"`
+	RequestID string    `db:"request_id"`
+	IP        string    `db:"ip"`
+	Before    []byte    `db:"before"`
+	After     []byte    `db:"after"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// toModel преобразует строку таблицы в модель записи журнала аудита
+func (row auditRow) toModel() models.AuditEntry {
+	return models.AuditEntry{
+		ID:        row.ID,
+		Entity:    row.Entity,
+		EntityID:  row.EntityID,
+		Action:    row.Action,
+		Actor:     row.Actor,
+		RequestID: row.RequestID,
+		IP:        row.IP,
+		Before:    row.Before,
+		After:     row.After,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// recordAudit добавляет запись в журнал аудита в рамках транзакции изменения. Инициатор
+// и сведения о запросе берутся из контекста. Пустое before или after (nil) не сохраняется.
+func recordAudit(ctx context.Context, tx *sqlx.Tx, entity string, entityID int, action string, before, after any) error {
+	beforeJSON, err := auditPayload(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditPayload(after)
+	if err != nil {
+		return err
+	}
+
+	req := actor.RequestFromContext(ctx)
+	query := `
+		INSERT INTO audit_log (entity, entity_id, action, actor, request_id, ip, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.ExecContext(ctx, query, entity, entityID, action, actor.FromContext(ctx), req.ID, req.IP, beforeJSON, afterJSON)
+	if err != nil {
+		log.Printf("Ошибка записи в журнал аудита: %v", err)
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+// auditPayload кодирует состояние сущности в JSON, для nil возвращает nil (NULL в базе данных)
+func auditPayload(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка кодирования состояния для журнала аудита: %w", err)
+	}
+	return payload, nil
+}
+
+// GetAuditLog получает записи журнала аудита по условиям filter, новые первыми
+func (r *PostgresRepository) GetAuditLog(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, entity, entity_id, action, actor, request_id, ip, before, after, created_at
+		FROM audit_log
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(condition, len(args))
+	}
+
+	if filter.Entity != "" {
+		addCondition(` AND entity = $%d`, filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		addCondition(` AND entity_id = $%d`, filter.EntityID)
+	}
+	if filter.Actor != "" {
+		addCondition(` AND actor = $%d`, filter.Actor)
+	}
+	if filter.From != nil {
+		addCondition(` AND created_at >= $%d`, *filter.From)
+	}
+	if filter.To != nil {
+		addCondition(` AND created_at <= $%d`, *filter.To)
+	}
+
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	var rows []auditRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		log.Printf("Ошибка получения журнала аудита: %v", err)
+		return nil, fmt.Errorf("ошибка получения журнала аудита: %w", err)
+	}
+
+	entries := make([]models.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.toModel())
+	}
+	return entries, nil
+}
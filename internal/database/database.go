@@ -3,15 +3,22 @@ package database
 import (
 	"context"
 	"music_library/internal/models"
+	"time"
 )
 
 // SongDB - интерфейс для работы с базой данных песен
 type SongDB interface {
-	// AddSong добавляет новую песню
-	AddSong(ctx context.Context, song models.Song) (int, error)
+	// AddSong добавляет новую песню, allowDuplicate разрешает сохранить дубликат
+	AddSong(ctx context.Context, song models.Song, allowDuplicate bool) (int, error)
 
-	// GetSongs получает список песен с фильтрацией и пагинацией
-	GetSongs(ctx context.Context, limit, offset int, filter models.Song) ([]models.Song, error)
+	// ExportSongs передает в fn песни, подходящие под фильтр, вместе с куплетами
+	ExportSongs(ctx context.Context, filter models.Song, fn func(models.Song) error) error
+
+	// FindSongsByGroupKey получает песни группы по нормализованному названию группы
+	FindSongsByGroupKey(ctx context.Context, groupKey string) ([]models.Song, error)
+
+	// GetSongs получает список песен с фильтрацией, сортировкой и пагинацией
+	GetSongs(ctx context.Context, limit, offset int, filter models.Song, sort string) ([]models.Song, error)
 
 	// GetSongByID получает песню по ID
 	GetSongByID(ctx context.Context, id int) (models.Song, error)
@@ -22,9 +29,207 @@ type SongDB interface {
 	// DeleteSong удаляет песню по ID
 	DeleteSong(ctx context.Context, id int) error
 
-	// AddVerses добавляет куплеты к песне
-	AddVerses(ctx context.Context, songID int, verses []models.Verse) error
+	// AddVerses добавляет куплеты к песне и записывает источник текста
+	AddVerses(ctx context.Context, songID int, verses []models.Verse, source string) error
+
+	// UpdateVerse изменяет текст куплета и переносит привязки аннотаций
+	UpdateVerse(ctx context.Context, songID, verseNumber int, text string) (models.Verse, error)
 
 	// GetVersesBySongID получает куплеты песни с пагинацией
 	GetVersesBySongID(ctx context.Context, songID, limit, offset int) ([]models.Verse, error)
+
+	// GetRevisions получает ревизии песни с пагинацией
+	GetRevisions(ctx context.Context, songID, limit, offset int) ([]models.SongRevision, error)
+
+	// GetRevision получает ревизию песни по номеру
+	GetRevision(ctx context.Context, songID, revision int) (models.SongRevision, error)
+
+	// RevertToRevision восстанавливает песню из ревизии
+	RevertToRevision(ctx context.Context, songID, revision int) error
+
+	// MergeSongs присоединяет песню sourceID к песне survivorID
+	MergeSongs(ctx context.Context, survivorID, sourceID int, policy models.MergePolicy) error
+
+	// GetSongMerges получает журнал слияний песни
+	GetSongMerges(ctx context.Context, survivorID int) ([]models.SongMerge, error)
+
+	// GetSongRedirect возвращает ID песни, к которой была присоединена песня
+	GetSongRedirect(ctx context.Context, oldID int) (int, error)
+
+	// ClaimEnrichmentTasks выбирает песни, ожидающие получения информации
+	ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]models.EnrichmentTask, error)
+
+	// CompleteEnrichment сохраняет полученную информацию о песне
+	CompleteEnrichment(ctx context.Context, songID int, details models.SongDetails, verses []models.Verse) error
+
+	// FailEnrichment записывает неудачную попытку получения информации о песне
+	FailEnrichment(ctx context.Context, songID int, message string, retryAt *time.Time) error
+
+	// ImportSongs добавляет или обновляет песни из партии строк импорта
+	ImportSongs(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) ([]models.ImportRowResult, error)
+
+	// RefreshSong обновляет песню по повторно полученной информации, dryRun только возвращает изменения
+	RefreshSong(ctx context.Context, songID int, details models.SongDetails, verses []models.Verse, dryRun bool) (models.RefreshResult, error)
+}
+
+// JobDB - интерфейс для работы с очередью фоновых задач
+type JobDB interface {
+	// CreateJob ставит в очередь новую задачу
+	CreateJob(ctx context.Context, kind string, payload []byte) (int, error)
+
+	// GetJob получает задачу по ID
+	GetJob(ctx context.Context, id int) (models.Job, error)
+
+	// ClaimJob выбирает следующую задачу из очереди для выполнения
+	ClaimJob(ctx context.Context) (models.Job, error)
+
+	// FinishJob сохраняет результат задачи
+	FinishJob(ctx context.Context, id int, result []byte, errMessage string) error
+
+	// HeartbeatJob отмечает, что задача еще выполняется
+	HeartbeatJob(ctx context.Context, id int) error
+
+	// RequeueStaleJobs возвращает в очередь зависшие задачи
+	RequeueStaleJobs(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+// ListeningDB - интерфейс для работы с избранным, оценками и прослушиваниями пользователей
+type ListeningDB interface {
+	// AddFavorite добавляет песню в избранное пользователя
+	AddFavorite(ctx context.Context, userID, songID int) error
+
+	// RemoveFavorite удаляет песню из избранного пользователя
+	RemoveFavorite(ctx context.Context, userID, songID int) error
+
+	// GetFavorites получает избранные песни пользователя с пагинацией
+	GetFavorites(ctx context.Context, userID, limit, offset int) ([]models.Favorite, error)
+
+	// SetRating сохраняет оценку песни пользователем
+	SetRating(ctx context.Context, userID, songID, rating int) (models.SongRating, error)
+
+	// GetRating получает оценку песни пользователем
+	GetRating(ctx context.Context, userID, songID int) (models.SongRating, error)
+
+	// DeleteRating удаляет оценку песни пользователем
+	DeleteRating(ctx context.Context, userID, songID int) error
+
+	// AddPlay записывает прослушивание песни пользователем
+	AddPlay(ctx context.Context, userID, songID int, playedAt time.Time) (int64, error)
+
+	// GetPlays получает историю прослушиваний пользователя с пагинацией
+	GetPlays(ctx context.Context, userID, limit, offset int) ([]models.Play, error)
+}
+
+// AnnotationDB - интерфейс для работы с аннотациями к тексту песен
+type AnnotationDB interface {
+	// CreateAnnotation добавляет аннотацию к диапазону символов куплета
+	CreateAnnotation(ctx context.Context, songID int, req models.AnnotationRequest) (models.Annotation, error)
+
+	// CreateReply добавляет ответ на аннотацию
+	CreateReply(ctx context.Context, songID, parentID int, body string) (models.Annotation, error)
+
+	// GetAnnotations получает аннотации песни и ответы на них в порядке добавления
+	GetAnnotations(ctx context.Context, songID int) ([]models.Annotation, error)
+
+	// UpdateAnnotation изменяет текст и, если указан куплет, привязку аннотации
+	UpdateAnnotation(ctx context.Context, songID, id int, upd models.AnnotationUpdate) (models.Annotation, error)
+
+	// DeleteAnnotation удаляет аннотацию вместе с ответами на нее
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+}
+
+// AuditDB - интерфейс для чтения журнала аудита. Записи добавляются в транзакциях изменений.
+type AuditDB interface {
+	// GetAuditLog получает записи журнала аудита по условиям отбора с пагинацией
+	GetAuditLog(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error)
+}
+
+// PlaylistDB - интерфейс для работы с плейлистами
+type PlaylistDB interface {
+	// CreatePlaylist создает плейлист с песнями из playlist.Items
+	CreatePlaylist(ctx context.Context, playlist models.Playlist) (int, error)
+
+	// GetPlaylists получает плейлисты без элементов с пагинацией
+	GetPlaylists(ctx context.Context, limit, offset int) ([]models.Playlist, error)
+
+	// GetPlaylist получает плейлист с песнями в порядке позиций
+	GetPlaylist(ctx context.Context, id int) (models.Playlist, error)
+
+	// UpdatePlaylist изменяет название и описание плейлиста
+	UpdatePlaylist(ctx context.Context, playlist models.Playlist) error
+
+	// DeletePlaylist удаляет плейлист вместе с элементами
+	DeletePlaylist(ctx context.Context, id int) error
+
+	// AddPlaylistItem вставляет песню в плейлист на позицию, нулевая позиция означает конец плейлиста
+	AddPlaylistItem(ctx context.Context, playlistID, songID, position int) (int, error)
+
+	// RemovePlaylistItem удаляет элемент плейлиста
+	RemovePlaylistItem(ctx context.Context, playlistID, itemID int) error
+
+	// MovePlaylistItem перемещает элемент плейлиста на позицию
+	MovePlaylistItem(ctx context.Context, playlistID, itemID, position int) error
+
+	// FindSongByKeys находит песню по нормализованным названиям группы и песни
+	FindSongByKeys(ctx context.Context, groupKey, songKey string) (models.Song, error)
+
+	// CreateSmartList сохраняет умный список
+	CreateSmartList(ctx context.Context, list models.SmartList) (int, error)
+
+	// GetSmartLists получает умные списки без песен с пагинацией
+	GetSmartLists(ctx context.Context, limit, offset int) ([]models.SmartList, error)
+
+	// GetSmartList получает умный список без песен
+	GetSmartList(ctx context.Context, id int) (models.SmartList, error)
+
+	// UpdateSmartList изменяет название, описание и правило умного списка
+	UpdateSmartList(ctx context.Context, list models.SmartList) error
+
+	// DeleteSmartList удаляет умный список
+	DeleteSmartList(ctx context.Context, id int) error
+
+	// GetSmartListSongs отбирает песни по правилу умного списка с пагинацией
+	GetSmartListSongs(ctx context.Context, rules models.SmartListRules, limit, offset int) ([]models.Song, error)
+}
+
+// UserDB - интерфейс для работы с пользователями, токенами обновления и ключами API
+type UserDB interface {
+	// CreateUser сохраняет пользователя с уже захэшированным паролем
+	CreateUser(ctx context.Context, user models.User) (int, error)
+
+	// GetUserByUsername получает пользователя по имени без учета регистра
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+
+	// GetUserByID получает пользователя по ID
+	GetUserByID(ctx context.Context, id int) (models.User, error)
+
+	// GetUsers получает всех пользователей
+	GetUsers(ctx context.Context) ([]models.User, error)
+
+	// SetUserRole изменяет роль пользователя
+	SetUserRole(ctx context.Context, id int, role string) error
+
+	// DeleteUser удаляет пользователя
+	DeleteUser(ctx context.Context, id int) error
+
+	// CreateRefreshToken сохраняет хэш нового токена обновления
+	CreateRefreshToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+
+	// RotateRefreshToken заменяет предъявленный токен обновления новым и возвращает его владельца
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (models.User, error)
+
+	// RevokeRefreshToken отзывает токен обновления
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+
+	// CreateAPIKey сохраняет ключ API по его хэшу
+	CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error)
+
+	// GetAPIKeys получает все ключи API
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+
+	// RevokeAPIKey отзывает ключ API
+	RevokeAPIKey(ctx context.Context, id int) error
+
+	// UseAPIKey находит действующий ключ API по хэшу и отмечает время его использования
+	UseAPIKey(ctx context.Context, keyHash string) (models.APIKey, error)
 }
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresDetailsCache хранит кэш информации о песнях в таблице details_cache,
+// поэтому кэш сохраняется между перезапусками сервиса
+type PostgresDetailsCache struct {
+	db *sqlx.DB
+}
+
+// NewPostgresDetailsCache создает новый PostgresDetailsCache
+func NewPostgresDetailsCache(db *sqlx.DB) *PostgresDetailsCache {
+	return &PostgresDetailsCache{db: db}
+}
+
+// detailsCacheRow - строка таблицы details_cache
+type detailsCacheRow struct {
+	ReleaseDate       string    `db:"release_date"`
+	Text              string    `db:"text"`
+	Link              string    `db:"link"`
+	ReleaseDateSource string    `db:"release_date_source"`
+	TextSource        string    `db:"text_source"`
+	LinkSource        string    `db:"link_source"`
+	NotFound          bool      `db:"not_found"`
+	ExpiresAt         time.Time `db:"expires_at"`
+}
+
+// toModel преобразует строку таблицы в запись кэша
+func (row detailsCacheRow) toModel() models.DetailsCacheEntry {
+	return models.DetailsCacheEntry{
+		Details: models.SongDetails{
+			ReleaseDate: row.ReleaseDate,
+			Text:        row.Text,
+			Link:        row.Link,
+			Provenance: models.DetailsProvenance{
+				ReleaseDate: row.ReleaseDateSource,
+				Text:        row.TextSource,
+				Link:        row.LinkSource,
+			},
+		},
+		NotFound:  row.NotFound,
+		ExpiresAt: row.ExpiresAt,
+	}
+}
+
+// Get возвращает неустаревшую запись кэша по ключу
+func (c *PostgresDetailsCache) Get(ctx context.Context, key string) (models.DetailsCacheEntry, bool, error) {
+	query := `
+		SELECT release_date, text, link, release_date_source, text_source, link_source, not_found, expires_at
+		FROM details_cache
+		WHERE key = $1 AND expires_at > now()
+	`
+
+	var row detailsCacheRow
+	err := c.db.GetContext(ctx, &row, query, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DetailsCacheEntry{}, false, nil
+	}
+	if err != nil {
+		log.Printf("Ошибка чтения кэша информации о песнях: %v", err)
+		return models.DetailsCacheEntry{}, false, fmt.Errorf("ошибка чтения кэша информации о песнях: %w", err)
+	}
+
+	return row.toModel(), true, nil
+}
+
+// DeleteExpired удаляет устаревшие записи кэша и возвращает их количество
+func (c *PostgresDetailsCache) DeleteExpired(ctx context.Context) (int, error) {
+	res, err := c.db.ExecContext(ctx, `DELETE FROM details_cache WHERE expires_at <= now()`)
+	if err != nil {
+		log.Printf("Ошибка удаления устаревших записей кэша информации о песнях: %v", err)
+		return 0, fmt.Errorf("ошибка удаления устаревших записей кэша информации о песнях: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка удаления устаревших записей кэша информации о песнях: %w", err)
+	}
+	return int(n), nil
+}
+
+// Set сохраняет запись кэша, заменяя предыдущую запись с тем же ключом
+func (c *PostgresDetailsCache) Set(ctx context.Context, key string, entry models.DetailsCacheEntry) error {
+	query := `
+		INSERT INTO details_cache (key, release_date, text, link, release_date_source, text_source, link_source, not_found, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (key) DO UPDATE
+		SET release_date = EXCLUDED.release_date,
+			text = EXCLUDED.text,
+			link = EXCLUDED.link,
+			release_date_source = EXCLUDED.release_date_source,
+			text_source = EXCLUDED.text_source,
+			link_source = EXCLUDED.link_source,
+			not_found = EXCLUDED.not_found,
+			expires_at = EXCLUDED.expires_at
+	`
+
+	details := entry.Details
+	_, err := c.db.ExecContext(ctx, query, key,
+		details.ReleaseDate, details.Text, details.Link,
+		details.Provenance.ReleaseDate, details.Provenance.Text, details.Provenance.Link,
+		entry.NotFound, entry.ExpiresAt)
+	if err != nil {
+		log.Printf("Ошибка записи в кэш информации о песнях: %v", err)
+		return fmt.Errorf("ошибка записи в кэш информации о песнях: %w", err)
+	}
+
+	return nil
+}
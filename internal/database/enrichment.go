@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+)
+
+// ClaimEnrichmentTasks выбирает песни, ожидающие получения информации, срок повтора которых наступил.
+// Выбранные песни откладываются на время lease, чтобы их не взял другой экземпляр сервиса.
+func (r *PostgresRepository) ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]models.EnrichmentTask, error) {
+	query := `
+		WITH claimed AS (
+			SELECT id
+			FROM songs
+			WHERE enrichment_status = $1 AND next_enrichment_at <= now()
+			ORDER BY next_enrichment_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE songs s
+		SET next_enrichment_at = now() + make_interval(secs => $3)
+		FROM claimed
+		WHERE s.id = claimed.id
+		RETURNING s.id, s."group", s.song, s.enrichment_attempts
+	`
+
+	var tasks []models.EnrichmentTask
+	err := r.db.SelectContext(ctx, &tasks, query, models.EnrichmentPending, limit, lease.Seconds())
+	if err != nil {
+		log.Printf("Ошибка выбора песен для получения информации: %v", err)
+		return nil, fmt.Errorf("ошибка выбора песен для получения информации: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// CompleteEnrichment сохраняет полученную информацию о песне и сохраняет ревизию в той же транзакции.
+// Дата релиза и ссылка заполняются, только если их не задал пользователь,
+// куплеты добавляются, только если у песни их еще нет.
+func (r *PostgresRepository) CompleteEnrichment(ctx context.Context, songID int, details models.SongDetails, verses []models.Verse) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+
+	addVerses := len(before.Verses) == 0 && len(verses) > 0
+	query := `
+		UPDATE songs
+		SET release_date = CASE WHEN $2 <> '' AND release_date_source <> $8 THEN $2 ELSE release_date END,
+			release_date_source = CASE WHEN $2 <> '' AND release_date_source <> $8 THEN $3 ELSE release_date_source END,
+			link = CASE WHEN $4 <> '' AND link_source <> $8 THEN $4 ELSE link END,
+			link_source = CASE WHEN $4 <> '' AND link_source <> $8 THEN $5 ELSE link_source END,
+			text_source = CASE WHEN $6 THEN $7 ELSE text_source END,
+			enrichment_status = $9,
+			enrichment_error = '',
+			next_enrichment_at = NULL
+		WHERE id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, songID,
+		details.ReleaseDate, details.Provenance.ReleaseDate,
+		details.Link, details.Provenance.Link,
+		addVerses, details.Provenance.Text,
+		models.SourceUser, models.EnrichmentEnriched)
+	if err != nil {
+		log.Printf("Ошибка сохранения информации о песне: %v", err)
+		return fmt.Errorf("ошибка сохранения информации о песне: %w", err)
+	}
+
+	if addVerses {
+		if err := insertVerses(ctx, tx, songID, verses); err != nil {
+			return err
+		}
+	}
+
+	after, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+	if addVerses {
+		if err := reanchorAnnotations(ctx, tx, songID, after.Verses); err != nil {
+			return err
+		}
+	}
+	if err := recordRevision(ctx, tx, songID, models.RevisionActionEnrich, before, after); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, songID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Информация о песне получена, ID: %d", songID)
+	return nil
+}
+
+// FailEnrichment записывает неудачную попытку получения информации о песне.
+// Если retryAt равен nil, попытки прекращаются и песня получает статус failed.
+func (r *PostgresRepository) FailEnrichment(ctx context.Context, songID int, message string, retryAt *time.Time) error {
+	status := models.EnrichmentPending
+	if retryAt == nil {
+		status = models.EnrichmentFailed
+	}
+
+	query := `
+		UPDATE songs
+		SET enrichment_status = $2,
+			enrichment_attempts = enrichment_attempts + 1,
+			enrichment_error = $3,
+			next_enrichment_at = $4
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, songID, status, message, retryAt); err != nil {
+		log.Printf("Ошибка записи неудачной попытки получения информации: %v", err)
+		return fmt.Errorf("ошибка записи неудачной попытки получения информации: %w", err)
+	}
+
+	return nil
+}
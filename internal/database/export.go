@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// exportBatchSize - количество песен, загружаемых за один запрос при выгрузке
+const exportBatchSize = 500
+
+// ExportSongs передает в fn песни, подходящие под фильтр, вместе с куплетами в порядке ID.
+// Песни читаются партиями по курсору (последнему выданному ID), поэтому в памяти находится
+// не больше одной партии. Ошибка fn прекращает выгрузку и возвращается.
+func (r *PostgresRepository) ExportSongs(ctx context.Context, filter models.Song, fn func(models.Song) error) error {
+	conditions, filterArgs := songFilterConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT id, "group", song, release_date, link, enrichment_status, %s
+		FROM songs
+		WHERE id > $%d %s
+		ORDER BY id
+		LIMIT $%d
+	`, provenanceColumns, len(filterArgs)+1, conditions, len(filterArgs)+2)
+
+	cursor := 0
+	for {
+		args := append(append([]interface{}{}, filterArgs...), cursor, exportBatchSize)
+
+		var songs []models.Song
+		if err := r.db.SelectContext(ctx, &songs, query, args...); err != nil {
+			log.Printf("Ошибка выгрузки песен: %v", err)
+			return fmt.Errorf("ошибка выгрузки песен: %w", err)
+		}
+		if len(songs) == 0 {
+			return nil
+		}
+
+		if err := r.loadVerses(ctx, songs); err != nil {
+			return err
+		}
+		for _, song := range songs {
+			if err := fn(song); err != nil {
+				return err
+			}
+		}
+
+		if len(songs) < exportBatchSize {
+			return nil
+		}
+		cursor = songs[len(songs)-1].ID
+	}
+}
+
+// loadVerses загружает куплеты для партии песен одним запросом
+func (r *PostgresRepository) loadVerses(ctx context.Context, songs []models.Song) error {
+	ids := make([]int64, len(songs))
+	index := make(map[int]int, len(songs))
+	for i, song := range songs {
+		ids[i] = int64(song.ID)
+		index[song.ID] = i
+		songs[i].Verses = []*models.Verse{}
+	}
+
+	query := `
+		SELECT id, song_id, verse_number, text
+		FROM verses
+		WHERE song_id = ANY($1)
+		ORDER BY song_id, verse_number, id
+	`
+	var verses []models.Verse
+	if err := r.db.SelectContext(ctx, &verses, query, pq.Array(ids)); err != nil {
+		log.Printf("Ошибка выгрузки куплетов: %v", err)
+		return fmt.Errorf("ошибка выгрузки куплетов: %w", err)
+	}
+
+	for i := range verses {
+		song := &songs[index[verses[i].SongID]]
+		song.Verses = append(song.Verses, &verses[i])
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportSongs сохраняет партию строк импорта в одной транзакции. Песня ищется по нормализованным
+// названиям группы и песни: новая песня добавляется, у существующей обновляются непустые дата релиза,
+// ссылка и куплеты, поэтому повторный импорт того же файла ничего не меняет. С opts.InsertOnly существующие
+// песни не изменяются, а строка получает результат пропуска. С opts.KeepExisting
+// заполненные поля не заменяются, а строка с расходящимися данными получает результат конфликта.
+// Ошибка строки откатывает только эту строку. С opts.DryRun транзакция откатывается целиком.
+func (r *PostgresRepository) ImportSongs(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) ([]models.ImportRowResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]models.ImportRowResult, 0, len(rows))
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+			log.Printf("Ошибка создания точки сохранения: %v", err)
+			return nil, fmt.Errorf("ошибка создания точки сохранения: %w", err)
+		}
+
+		result := models.ImportRowResult{Line: row.Line, Source: row.Source, Group: row.Group, Song: row.Song}
+		result.SongID, result.Status, result.Conflicts, err = importRow(ctx, tx, row, opts)
+		release := `RELEASE SAVEPOINT import_row`
+		if err != nil {
+			result.Status, result.Error = models.ImportFailed, err.Error()
+			release = `ROLLBACK TO SAVEPOINT import_row`
+		}
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			log.Printf("Ошибка завершения точки сохранения: %v", err)
+			return nil, fmt.Errorf("ошибка завершения точки сохранения: %w", err)
+		}
+
+		if opts.DryRun {
+			result.SongID = 0
+		}
+		results = append(results, result)
+	}
+
+	if opts.DryRun {
+		return results, nil
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return results, nil
+}
+
+// importRow добавляет или обновляет песню из строки импорта и возвращает ее ID, результат
+// и, если задан opts.KeepExisting, поля, расходящиеся с заполненными полями песни
+func importRow(ctx context.Context, tx *sqlx.Tx, row models.ImportRow, opts models.ImportOptions) (int, string, []string, error) {
+	var id int
+	query := `SELECT id FROM songs WHERE group_key = $1 AND song_key = $2 ORDER BY id LIMIT 1`
+	err := tx.GetContext(ctx, &id, query, normalize.Key(row.Group), normalize.Key(row.Song))
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = importNewSong(ctx, tx, row)
+		return id, models.ImportCreated, nil, err
+	}
+	if err != nil {
+		log.Printf("Ошибка поиска песни для импорта: %v", err)
+		return 0, "", nil, fmt.Errorf("ошибка поиска песни: %w", err)
+	}
+	if opts.InsertOnly {
+		return id, models.ImportSkipped, nil, nil
+	}
+
+	before, err := loadSnapshot(ctx, tx, id)
+	if err != nil {
+		return 0, "", nil, err
+	}
+
+	// merge решает, заменить ли значение поля: пустые и совпадающие значения пропускаются,
+	// а с opts.KeepExisting заполненное значение не заменяется и поле считается конфликтующим
+	var conflicts []string
+	merge := func(field string, changed, filled bool) bool {
+		if !changed {
+			return false
+		}
+		if opts.KeepExisting && filled {
+			conflicts = append(conflicts, field)
+			return false
+		}
+		return true
+	}
+
+	after := before
+	if merge("release_date", row.ReleaseDate != "" && row.ReleaseDate != before.ReleaseDate, before.ReleaseDate != "") {
+		after.ReleaseDate = row.ReleaseDate
+	}
+	if merge("link", row.Link != "" && row.Link != before.Link, before.Link != "") {
+		after.Link = row.Link
+	}
+	replaceVerses := merge("verses", len(row.Verses) > 0 && !sameVerseTexts(before.Verses, row.Verses), len(before.Verses) > 0)
+	if replaceVerses {
+		after.Verses = row.Verses
+	}
+	if len(conflicts) > 0 {
+		return id, models.ImportConflict, conflicts, nil
+	}
+	if len(diffSnapshots(before, after)) == 0 {
+		return id, models.ImportUnchanged, nil, nil
+	}
+
+	query = `
+		UPDATE songs
+		SET release_date = $2, link = $3,
+			release_date_source = CASE WHEN release_date IS DISTINCT FROM $2 THEN $5 ELSE release_date_source END,
+			link_source = CASE WHEN link IS DISTINCT FROM $3 THEN $5 ELSE link_source END,
+			text_source = CASE WHEN $4 THEN $5 ELSE text_source END
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id, after.ReleaseDate, after.Link, replaceVerses, models.SourceUser); err != nil {
+		log.Printf("Ошибка обновления песни при импорте: %v", err)
+		return 0, "", nil, fmt.Errorf("ошибка обновления песни: %w", err)
+	}
+	if replaceVerses {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM verses WHERE song_id = $1`, id); err != nil {
+			log.Printf("Ошибка удаления куплетов при импорте: %v", err)
+			return 0, "", nil, fmt.Errorf("ошибка удаления куплетов: %w", err)
+		}
+		if err := insertVerses(ctx, tx, id, row.Verses); err != nil {
+			return 0, "", nil, err
+		}
+	}
+
+	after, err = loadSnapshot(ctx, tx, id)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if replaceVerses {
+		if err := reanchorAnnotations(ctx, tx, id, after.Verses); err != nil {
+			return 0, "", nil, err
+		}
+	}
+	if err := recordRevision(ctx, tx, id, models.RevisionActionImport, before, after); err != nil {
+		return 0, "", nil, err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, id, models.AuditActionUpdate, before, after); err != nil {
+		return 0, "", nil, err
+	}
+	return id, models.ImportUpdated, nil, nil
+}
+
+// importNewSong добавляет песню из строки импорта. Данные файла считаются введенными пользователем.
+// Если в строке заполнены не все поля, песня ожидает получения недостающей информации из источника.
+func importNewSong(ctx context.Context, tx *sqlx.Tx, row models.ImportRow) (int, error) {
+	song := models.Song{
+		Group:            row.Group,
+		Song:             row.Song,
+		ReleaseDate:      row.ReleaseDate,
+		Link:             row.Link,
+		EnrichmentStatus: models.EnrichmentEnriched,
+	}
+	if row.ReleaseDate != "" {
+		song.Provenance.ReleaseDate = models.SourceUser
+	}
+	if row.Link != "" {
+		song.Provenance.Link = models.SourceUser
+	}
+	if len(row.Verses) > 0 {
+		song.Provenance.Text = models.SourceUser
+	}
+	if row.ReleaseDate == "" || row.Link == "" || len(row.Verses) == 0 {
+		song.EnrichmentStatus = models.EnrichmentPending
+	}
+
+	id, err := insertSong(ctx, tx, song, false)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertVerses(ctx, tx, id, row.Verses); err != nil {
+		return 0, err
+	}
+
+	after, err := loadSnapshot(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordRevision(ctx, tx, id, models.RevisionActionImport, models.SongSnapshot{}, after); err != nil {
+		return 0, err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, id, models.AuditActionCreate, nil, after); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/models"
+	"time"
+)
+
+// jobColumns - столбцы таблицы jobs для выборки в models.Job
+const jobColumns = `id, kind, status, payload, result, error, created_at, started_at, finished_at, actor, request_id, ip`
+
+// CreateJob ставит в очередь новую фоновую задачу. Инициатор и сведения о запросе
+// берутся из контекста и сохраняются отдельно от параметров задачи.
+func (r *PostgresRepository) CreateJob(ctx context.Context, kind string, payload []byte) (int, error) {
+	query := `
+		INSERT INTO jobs (kind, status, payload, actor, request_id, ip)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	req := actor.RequestFromContext(ctx)
+	var id int
+	err := r.db.QueryRowxContext(ctx, query, kind, models.JobQueued, payload, actor.FromContext(ctx), req.ID, req.IP).Scan(&id)
+	if err != nil {
+		log.Printf("Ошибка создания задачи: %v", err)
+		return 0, fmt.Errorf("ошибка создания задачи: %w", err)
+	}
+
+	log.Printf("Задача поставлена в очередь, ID: %d", id)
+	return id, nil
+}
+
+// GetJob получает задачу по ID
+func (r *PostgresRepository) GetJob(ctx context.Context, id int) (models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	var job models.Job
+	err := r.db.GetContext(ctx, &job, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, fmt.Errorf("задача %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения задачи: %v", err)
+		return models.Job{}, fmt.Errorf("ошибка получения задачи: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimJob переводит самую старую задачу из очереди в статус running и возвращает ее.
+// Если очередь пуста, возвращается ошибка, совместимая с models.ErrNotFound.
+func (r *PostgresRepository) ClaimJob(ctx context.Context) (models.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = $1, started_at = now(), heartbeat_at = now()
+		WHERE id = (
+			SELECT id
+			FROM jobs
+			WHERE status = $2
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	var job models.Job
+	err := r.db.GetContext(ctx, &job, query, models.JobRunning, models.JobQueued)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, fmt.Errorf("очередь задач пуста: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка выбора задачи: %v", err)
+		return models.Job{}, fmt.Errorf("ошибка выбора задачи: %w", err)
+	}
+
+	return job, nil
+}
+
+// FinishJob сохраняет результат задачи. Если errMessage не пустой, задача завершается со статусом failed.
+func (r *PostgresRepository) FinishJob(ctx context.Context, id int, result []byte, errMessage string) error {
+	status := models.JobSucceeded
+	if errMessage != "" {
+		status = models.JobFailed
+	}
+
+	query := `
+		UPDATE jobs
+		SET status = $2, result = $3, error = $4, finished_at = now()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, status, result, errMessage); err != nil {
+		log.Printf("Ошибка завершения задачи: %v", err)
+		return fmt.Errorf("ошибка завершения задачи: %w", err)
+	}
+
+	return nil
+}
+
+// HeartbeatJob отмечает, что обработчик продолжает выполнять задачу
+func (r *PostgresRepository) HeartbeatJob(ctx context.Context, id int) error {
+	query := `UPDATE jobs SET heartbeat_at = now() WHERE id = $1 AND status = $2`
+	if _, err := r.db.ExecContext(ctx, query, id, models.JobRunning); err != nil {
+		log.Printf("Ошибка обновления отметки выполнения задачи: %v", err)
+		return fmt.Errorf("ошибка обновления отметки выполнения задачи: %w", err)
+	}
+	return nil
+}
+
+// RequeueStaleJobs возвращает в очередь выполняющиеся задачи, отметка выполнения которых
+// не обновлялась дольше olderThan. Такие задачи остаются после аварийного завершения обработчика.
+func (r *PostgresRepository) RequeueStaleJobs(ctx context.Context, olderThan time.Duration) (int, error) {
+	query := `
+		UPDATE jobs
+		SET status = $1, started_at = NULL, heartbeat_at = NULL
+		WHERE status = $2 AND COALESCE(heartbeat_at, started_at) < now() - make_interval(secs => $3)
+	`
+	res, err := r.db.ExecContext(ctx, query, models.JobQueued, models.JobRunning, olderThan.Seconds())
+	if err != nil {
+		log.Printf("Ошибка возврата зависших задач в очередь: %v", err)
+		return 0, fmt.Errorf("ошибка возврата зависших задач в очередь: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка возврата зависших задач в очередь: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+)
+
+// AddFavorite добавляет песню в избранное пользователя. Повторное добавление не считается ошибкой.
+func (r *PostgresRepository) AddFavorite(ctx context.Context, userID, songID int) error {
+	query := `INSERT INTO favorites (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, userID, songID)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("песня с ID %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка добавления песни в избранное: %v", err)
+		return fmt.Errorf("ошибка добавления песни в избранное: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavorite удаляет песню из избранного пользователя
+func (r *PostgresRepository) RemoveFavorite(ctx context.Context, userID, songID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM favorites WHERE user_id = $1 AND song_id = $2`, userID, songID)
+	if err != nil {
+		log.Printf("Ошибка удаления песни из избранного: %v", err)
+		return fmt.Errorf("ошибка удаления песни из избранного: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("песня %d в избранном: %w", songID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// GetFavorites получает избранные песни пользователя, недавно добавленные первыми
+func (r *PostgresRepository) GetFavorites(ctx context.Context, userID, limit, offset int) ([]models.Favorite, error) {
+	query := `
+		SELECT f.added_at, ` + songItemColumns + `
+		FROM favorites f
+		JOIN songs s ON s.id = f.song_id
+		WHERE f.user_id = $1
+		ORDER BY f.added_at DESC, f.song_id
+		LIMIT $2 OFFSET $3
+	`
+
+	favorites := []models.Favorite{}
+	if err := r.db.SelectContext(ctx, &favorites, query, userID, limit, offset); err != nil {
+		log.Printf("Ошибка получения избранного: %v", err)
+		return nil, fmt.Errorf("ошибка получения избранного: %w", err)
+	}
+	return favorites, nil
+}
+
+// SetRating сохраняет оценку песни пользователем, заменяя прежнюю
+func (r *PostgresRepository) SetRating(ctx context.Context, userID, songID, rating int) (models.SongRating, error) {
+	query := `
+		INSERT INTO song_ratings (user_id, song_id, rating)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, song_id) DO UPDATE SET rating = EXCLUDED.rating, rated_at = now()
+		RETURNING song_id, rating, rated_at
+	`
+
+	var result models.SongRating
+	err := r.db.GetContext(ctx, &result, query, userID, songID, rating)
+	if isForeignKeyViolation(err) {
+		return models.SongRating{}, fmt.Errorf("песня с ID %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка сохранения оценки: %v", err)
+		return models.SongRating{}, fmt.Errorf("ошибка сохранения оценки: %w", err)
+	}
+	return result, nil
+}
+
+// GetRating получает оценку песни пользователем
+func (r *PostgresRepository) GetRating(ctx context.Context, userID, songID int) (models.SongRating, error) {
+	query := `SELECT song_id, rating, rated_at FROM song_ratings WHERE user_id = $1 AND song_id = $2`
+
+	var result models.SongRating
+	err := r.db.GetContext(ctx, &result, query, userID, songID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SongRating{}, fmt.Errorf("оценка песни %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения оценки: %v", err)
+		return models.SongRating{}, fmt.Errorf("ошибка получения оценки: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteRating удаляет оценку песни пользователем
+func (r *PostgresRepository) DeleteRating(ctx context.Context, userID, songID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM song_ratings WHERE user_id = $1 AND song_id = $2`, userID, songID)
+	if err != nil {
+		log.Printf("Ошибка удаления оценки: %v", err)
+		return fmt.Errorf("ошибка удаления оценки: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("оценка песни %d: %w", songID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// AddPlay записывает прослушивание песни пользователем и возвращает ID записи
+func (r *PostgresRepository) AddPlay(ctx context.Context, userID, songID int, playedAt time.Time) (int64, error) {
+	query := `INSERT INTO plays (user_id, song_id, played_at) VALUES ($1, $2, $3) RETURNING id`
+
+	var id int64
+	err := r.db.QueryRowxContext(ctx, query, userID, songID, playedAt).Scan(&id)
+	if isForeignKeyViolation(err) {
+		return 0, fmt.Errorf("песня с ID %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи прослушивания: %v", err)
+		return 0, fmt.Errorf("ошибка записи прослушивания: %w", err)
+	}
+	return id, nil
+}
+
+// GetPlays получает историю прослушиваний пользователя, последние первыми
+func (r *PostgresRepository) GetPlays(ctx context.Context, userID, limit, offset int) ([]models.Play, error) {
+	query := `
+		SELECT p.id, p.played_at, ` + songItemColumns + `
+		FROM plays p
+		JOIN songs s ON s.id = p.song_id
+		WHERE p.user_id = $1
+		ORDER BY p.played_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	plays := []models.Play{}
+	if err := r.db.SelectContext(ctx, &plays, query, userID, limit, offset); err != nil {
+		log.Printf("Ошибка получения истории прослушиваний: %v", err)
+		return nil, fmt.Errorf("ошибка получения истории прослушиваний: %w", err)
+	}
+	return plays, nil
+}
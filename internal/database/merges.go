@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+	"time"
+)
+
+// mergeRow - строка таблицы song_merges, политика и снимок хранятся в JSONB
+type mergeRow struct {
+	ID             int       `db:"id"`
+	SurvivorID     int       `db:"survivor_id"`
+	MergedID       int       `db:"merged_id"`
+	Author         string    `db:"author"`
+	Policy         []byte    `db:"policy"`
+	MergedSnapshot []byte    `db:"merged_snapshot"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// toModel преобразует строку таблицы в модель записи журнала слияний
+func (row mergeRow) toModel() (models.SongMerge, error) {
+	merge := models.SongMerge{
+		ID:         row.ID,
+		SurvivorID: row.SurvivorID,
+		MergedID:   row.MergedID,
+		Author:     row.Author,
+		CreatedAt:  row.CreatedAt,
+	}
+	if err := json.Unmarshal(row.Policy, &merge.Policy); err != nil {
+		return models.SongMerge{}, fmt.Errorf("ошибка декодирования политики слияния: %w", err)
+	}
+	if err := json.Unmarshal(row.MergedSnapshot, &merge.MergedSnapshot); err != nil {
+		return models.SongMerge{}, fmt.Errorf("ошибка декодирования снимка присоединенной песни: %w", err)
+	}
+	return merge, nil
+}
+
+// MergeSongs присоединяет песню sourceID к песне survivorID по правилам policy.
+// Присоединенная песня удаляется, ее ID перенаправляется на сохраняемую песню,
+// а слияние записывается в журнал и в историю изменений сохраняемой песни.
+func (r *PostgresRepository) MergeSongs(ctx context.Context, survivorID, sourceID int, policy models.MergePolicy) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строки блокируются в порядке возрастания ID, чтобы встречные слияния не приводили к взаимоблокировке
+	snapshots := make(map[int]models.SongSnapshot, 2)
+	for _, id := range []int{min(survivorID, sourceID), max(survivorID, sourceID)} {
+		snapshot, err := loadSnapshot(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		snapshots[id] = snapshot
+	}
+	survivor, source := snapshots[survivorID], snapshots[sourceID]
+
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования политики слияния: %w", err)
+	}
+	sourceJSON, err := json.Marshal(source)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования снимка присоединенной песни: %w", err)
+	}
+
+	query := `
+		INSERT INTO song_merges (survivor_id, merged_id, author, policy, merged_snapshot)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.ExecContext(ctx, query, survivorID, sourceID, actor.FromContext(ctx), policyJSON, sourceJSON); err != nil {
+		log.Printf("Ошибка записи в журнал слияний: %v", err)
+		return fmt.Errorf("ошибка записи в журнал слияний: %w", err)
+	}
+
+	// Журнал, перенаправления, элементы плейлистов, избранное, оценки, прослушивания и аннотации
+	// присоединяемой песни переходят к сохраняемой до ее удаления, иначе они будут удалены каскадно. Если пользователь
+	// уже добавил в избранное или оценил сохраняемую песню, остаются его избранное и оценка для нее.
+	statements := []string{
+		`UPDATE song_merges SET survivor_id = $1 WHERE survivor_id = $2`,
+		`UPDATE song_redirects SET target_id = $1 WHERE target_id = $2`,
+		`UPDATE playlist_items SET song_id = $1 WHERE song_id = $2`,
+		`INSERT INTO favorites (user_id, song_id, added_at)
+			SELECT user_id, $1, added_at FROM favorites WHERE song_id = $2
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO song_ratings (user_id, song_id, rating, rated_at)
+			SELECT user_id, $1, rating, rated_at FROM song_ratings WHERE song_id = $2
+			ON CONFLICT DO NOTHING`,
+		`UPDATE plays SET song_id = $1 WHERE song_id = $2`,
+		`UPDATE annotations SET song_id = $1 WHERE song_id = $2`,
+		`INSERT INTO song_redirects (old_id, target_id) VALUES ($2, $1)`,
+		`DELETE FROM songs WHERE id = $2`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, survivorID, sourceID); err != nil {
+			log.Printf("Ошибка слияния песен: %v", err)
+			return fmt.Errorf("ошибка слияния песен: %w", err)
+		}
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, sourceID, models.AuditActionDelete, source, nil); err != nil {
+		return err
+	}
+
+	merged, versesChanged := applyMergePolicy(survivor, source, policy)
+
+	query = `
+		UPDATE songs
+		SET "group" = $1, song = $2, release_date = $3, link = $4, group_key = $5, song_key = $6
+		WHERE id = $7
+	`
+	_, err = tx.ExecContext(ctx, query, merged.Group, merged.Song, merged.ReleaseDate, merged.Link,
+		normalize.Key(merged.Group), normalize.Key(merged.Song), survivorID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%s - %s: %w", merged.Group, merged.Song, models.ErrDuplicate)
+	}
+	if err != nil {
+		log.Printf("Ошибка обновления сохраняемой песни: %v", err)
+		return fmt.Errorf("ошибка обновления сохраняемой песни: %w", err)
+	}
+
+	if versesChanged {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM verses WHERE song_id = $1`, survivorID); err != nil {
+			log.Printf("Ошибка удаления куплетов при слиянии: %v", err)
+			return fmt.Errorf("ошибка удаления куплетов при слиянии: %w", err)
+		}
+		if err := insertVerses(ctx, tx, survivorID, merged.Verses); err != nil {
+			return err
+		}
+	}
+
+	// Аннотации присоединенной песни привязаны к ее куплетам, поэтому привязки всех аннотаций
+	// переносятся на итоговый текст сохраняемой песни
+	after, err := loadSnapshot(ctx, tx, survivorID)
+	if err != nil {
+		return err
+	}
+	if err := reanchorAnnotations(ctx, tx, survivorID, after.Verses); err != nil {
+		return err
+	}
+	if err := recordRevision(ctx, tx, survivorID, models.RevisionActionMerge, survivor, after); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, survivorID, models.AuditActionUpdate, survivor, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Песня %d присоединена к песне %d", sourceID, survivorID)
+	return nil
+}
+
+// GetSongMerges получает журнал слияний, в которых песня была сохраняемой
+func (r *PostgresRepository) GetSongMerges(ctx context.Context, survivorID int) ([]models.SongMerge, error) {
+	query := `
+		SELECT id, survivor_id, merged_id, author, policy, merged_snapshot, created_at
+		FROM song_merges
+		WHERE survivor_id = $1
+		ORDER BY id DESC
+	`
+
+	var rows []mergeRow
+	if err := r.db.SelectContext(ctx, &rows, query, survivorID); err != nil {
+		log.Printf("Ошибка получения журнала слияний: %v", err)
+		return nil, fmt.Errorf("ошибка получения журнала слияний: %w", err)
+	}
+
+	merges := make([]models.SongMerge, 0, len(rows))
+	for _, row := range rows {
+		merge, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, merge)
+	}
+
+	return merges, nil
+}
+
+// GetSongRedirect возвращает ID песни, к которой была присоединена песня с указанным ID
+func (r *PostgresRepository) GetSongRedirect(ctx context.Context, oldID int) (int, error) {
+	var targetID int
+	err := r.db.GetContext(ctx, &targetID, `SELECT target_id FROM song_redirects WHERE old_id = $1`, oldID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("перенаправление для песни %d: %w", oldID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения перенаправления: %v", err)
+		return 0, fmt.Errorf("ошибка получения перенаправления: %w", err)
+	}
+
+	return targetID, nil
+}
+
+// applyMergePolicy вычисляет состояние сохраняемой песни после слияния.
+// Второе значение сообщает, изменился ли набор куплетов.
+func applyMergePolicy(survivor, source models.SongSnapshot, policy models.MergePolicy) (models.SongSnapshot, bool) {
+	policy = policy.WithDefaults()
+
+	merged := models.SongSnapshot{
+		Group:       mergeField(policy.Group, survivor.Group, source.Group),
+		Song:        mergeField(policy.Song, survivor.Song, source.Song),
+		ReleaseDate: mergeField(policy.ReleaseDate, survivor.ReleaseDate, source.ReleaseDate),
+		Link:        mergeField(policy.Link, survivor.Link, source.Link),
+		Verses:      survivor.Verses,
+	}
+
+	switch policy.Verses {
+	case models.MergeReplace:
+		merged.Verses = source.Verses
+	case models.MergeFillEmpty:
+		if len(survivor.Verses) > 0 {
+			return merged, false
+		}
+		merged.Verses = source.Verses
+	case models.MergeAppend:
+		next := 0
+		for _, verse := range survivor.Verses {
+			next = max(next, verse.VerseNumber)
+		}
+		merged.Verses = append([]models.Verse{}, survivor.Verses...)
+		for _, verse := range source.Verses {
+			next++
+			verse.VerseNumber = next
+			merged.Verses = append(merged.Verses, verse)
+		}
+	default:
+		return merged, false
+	}
+
+	return merged, true
+}
+
+// mergeField выбирает значение поля по правилу слияния
+func mergeField(rule, survivor, source string) string {
+	switch rule {
+	case models.MergeKeep:
+		return survivor
+	case models.MergeReplace:
+		return source
+	default:
+		if survivor == "" {
+			return source
+		}
+		return survivor
+	}
+}
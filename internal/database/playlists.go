@@ -0,0 +1,360 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreatePlaylist создает плейлист. Песни из playlist.Items добавляются в том же порядке,
+// для элементов используется только ID песни.
+func (r *PostgresRepository) CreatePlaylist(ctx context.Context, playlist models.Playlist) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO playlists (name, description)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+
+	var id int
+	if err := tx.QueryRowxContext(ctx, query, playlist.Name, playlist.Description).Scan(&id); err != nil {
+		log.Printf("Ошибка создания плейлиста: %v", err)
+		return 0, fmt.Errorf("ошибка создания плейлиста: %w", err)
+	}
+
+	query = `INSERT INTO playlist_items (playlist_id, song_id, position) VALUES ($1, $2, $3)`
+	for i, item := range playlist.Items {
+		_, err := tx.ExecContext(ctx, query, id, item.Song.ID, i+1)
+		if isForeignKeyViolation(err) {
+			return 0, fmt.Errorf("песня с ID %d: %w", item.Song.ID, models.ErrNotFound)
+		}
+		if err != nil {
+			log.Printf("Ошибка добавления песни в плейлист: %v", err)
+			return 0, fmt.Errorf("ошибка добавления песни в плейлист: %w", err)
+		}
+	}
+
+	after, err := loadPlaylistSnapshot(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntityPlaylist, id, models.AuditActionCreate, nil, after); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Плейлист создан, ID: %d", id)
+	return id, nil
+}
+
+// GetPlaylists получает плейлисты без элементов с пагинацией
+func (r *PostgresRepository) GetPlaylists(ctx context.Context, limit, offset int) ([]models.Playlist, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM playlists
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	playlists := []models.Playlist{}
+	if err := r.db.SelectContext(ctx, &playlists, query, limit, offset); err != nil {
+		log.Printf("Ошибка получения плейлистов: %v", err)
+		return nil, fmt.Errorf("ошибка получения плейлистов: %w", err)
+	}
+
+	return playlists, nil
+}
+
+// GetPlaylist получает плейлист с песнями в порядке позиций
+func (r *PostgresRepository) GetPlaylist(ctx context.Context, id int) (models.Playlist, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM playlists WHERE id = $1`
+
+	var playlist models.Playlist
+	err := r.db.GetContext(ctx, &playlist, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Playlist{}, fmt.Errorf("плейлист %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения плейлиста: %v", err)
+		return models.Playlist{}, fmt.Errorf("ошибка получения плейлиста: %w", err)
+	}
+
+	query = `
+		SELECT pi.id, pi.position, pi.added_at, ` + songItemColumns + `
+		FROM playlist_items pi
+		JOIN songs s ON s.id = pi.song_id
+		WHERE pi.playlist_id = $1
+		ORDER BY pi.position, pi.id
+	`
+	playlist.Items = []models.PlaylistItem{}
+	if err := r.db.SelectContext(ctx, &playlist.Items, query, id); err != nil {
+		log.Printf("Ошибка получения элементов плейлиста: %v", err)
+		return models.Playlist{}, fmt.Errorf("ошибка получения элементов плейлиста: %w", err)
+	}
+
+	return playlist, nil
+}
+
+// UpdatePlaylist изменяет название и описание плейлиста
+func (r *PostgresRepository) UpdatePlaylist(ctx context.Context, playlist models.Playlist) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadPlaylistSnapshot(ctx, tx, playlist.ID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE playlists
+		SET name = $2, description = $3, updated_at = now()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, playlist.ID, playlist.Name, playlist.Description); err != nil {
+		log.Printf("Ошибка обновления плейлиста: %v", err)
+		return fmt.Errorf("ошибка обновления плейлиста: %w", err)
+	}
+
+	after := before
+	after.Name = playlist.Name
+	after.Description = playlist.Description
+	if err := recordAudit(ctx, tx, models.AuditEntityPlaylist, playlist.ID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// DeletePlaylist удаляет плейлист вместе с элементами
+func (r *PostgresRepository) DeletePlaylist(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadPlaylistSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM playlists WHERE id = $1`, id); err != nil {
+		log.Printf("Ошибка удаления плейлиста: %v", err)
+		return fmt.Errorf("ошибка удаления плейлиста: %w", err)
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntityPlaylist, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Плейлист удален, ID: %d", id)
+	return nil
+}
+
+// AddPlaylistItem вставляет песню в плейлист на позицию position, сдвигая последующие элементы.
+// Нулевая позиция означает конец плейлиста.
+func (r *PostgresRepository) AddPlaylistItem(ctx context.Context, playlistID, songID, position int) (int, error) {
+	var id int
+	err := r.withPlaylist(ctx, playlistID, func(tx *sqlx.Tx, count int) error {
+		if position == 0 {
+			position = count + 1
+		}
+		if position < 1 || position > count+1 {
+			return fmt.Errorf("%w: позиция %d вне плейлиста из %d элементов", models.ErrInvalidInput, position, count)
+		}
+
+		query := `UPDATE playlist_items SET position = position + 1 WHERE playlist_id = $1 AND position >= $2`
+		if _, err := tx.ExecContext(ctx, query, playlistID, position); err != nil {
+			log.Printf("Ошибка сдвига элементов плейлиста: %v", err)
+			return fmt.Errorf("ошибка сдвига элементов плейлиста: %w", err)
+		}
+
+		query = `
+			INSERT INTO playlist_items (playlist_id, song_id, position)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`
+		err := tx.QueryRowxContext(ctx, query, playlistID, songID, position).Scan(&id)
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("песня с ID %d: %w", songID, models.ErrNotFound)
+		}
+		if err != nil {
+			log.Printf("Ошибка добавления песни в плейлист: %v", err)
+			return fmt.Errorf("ошибка добавления песни в плейлист: %w", err)
+		}
+		return nil
+	})
+	return id, err
+}
+
+// RemovePlaylistItem удаляет элемент плейлиста, последующие элементы сдвигаются на его место
+func (r *PostgresRepository) RemovePlaylistItem(ctx context.Context, playlistID, itemID int) error {
+	return r.withPlaylist(ctx, playlistID, func(tx *sqlx.Tx, count int) error {
+		var position int
+		query := `DELETE FROM playlist_items WHERE id = $1 AND playlist_id = $2 RETURNING position`
+		err := tx.GetContext(ctx, &position, query, itemID, playlistID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("элемент %d плейлиста %d: %w", itemID, playlistID, models.ErrNotFound)
+		}
+		if err != nil {
+			log.Printf("Ошибка удаления элемента плейлиста: %v", err)
+			return fmt.Errorf("ошибка удаления элемента плейлиста: %w", err)
+		}
+
+		query = `UPDATE playlist_items SET position = position - 1 WHERE playlist_id = $1 AND position > $2`
+		if _, err := tx.ExecContext(ctx, query, playlistID, position); err != nil {
+			log.Printf("Ошибка сдвига элементов плейлиста: %v", err)
+			return fmt.Errorf("ошибка сдвига элементов плейлиста: %w", err)
+		}
+		return nil
+	})
+}
+
+// MovePlaylistItem перемещает элемент плейлиста на позицию position,
+// элементы между старой и новой позицией сдвигаются на одну позицию
+func (r *PostgresRepository) MovePlaylistItem(ctx context.Context, playlistID, itemID, position int) error {
+	return r.withPlaylist(ctx, playlistID, func(tx *sqlx.Tx, count int) error {
+		if position < 1 || position > count {
+			return fmt.Errorf("%w: позиция %d вне плейлиста из %d элементов", models.ErrInvalidInput, position, count)
+		}
+
+		var current int
+		query := `SELECT position FROM playlist_items WHERE id = $1 AND playlist_id = $2`
+		err := tx.GetContext(ctx, &current, query, itemID, playlistID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("элемент %d плейлиста %d: %w", itemID, playlistID, models.ErrNotFound)
+		}
+		if err != nil {
+			log.Printf("Ошибка получения элемента плейлиста: %v", err)
+			return fmt.Errorf("ошибка получения элемента плейлиста: %w", err)
+		}
+
+		query = `
+			UPDATE playlist_items
+			SET position = CASE
+				WHEN id = $2 THEN $4::int
+				WHEN $4::int < $3::int THEN position + 1
+				ELSE position - 1
+			END
+			WHERE playlist_id = $1 AND position BETWEEN LEAST($3::int, $4::int) AND GREATEST($3::int, $4::int)
+		`
+		if _, err := tx.ExecContext(ctx, query, playlistID, itemID, current, position); err != nil {
+			log.Printf("Ошибка перемещения элемента плейлиста: %v", err)
+			return fmt.Errorf("ошибка перемещения элемента плейлиста: %w", err)
+		}
+		return nil
+	})
+}
+
+// withPlaylist выполняет изменение элементов плейлиста в транзакции. Строка плейлиста блокируется,
+// чтобы одновременные изменения не перепутали позиции, а позиции перед изменением приводятся
+// к последовательности с единицы (после каскадного удаления песен в ней могут быть пропуски).
+// В fn передается количество элементов плейлиста, изменение записывается в журнал аудита.
+func (r *PostgresRepository) withPlaylist(ctx context.Context, playlistID int, fn func(tx *sqlx.Tx, count int) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadPlaylistSnapshot(ctx, tx, playlistID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE playlist_items pi
+		SET position = numbered.rn
+		FROM (
+			SELECT id, row_number() OVER (ORDER BY position, id) AS rn
+			FROM playlist_items
+			WHERE playlist_id = $1
+		) numbered
+		WHERE pi.id = numbered.id AND pi.position <> numbered.rn
+	`
+	if _, err := tx.ExecContext(ctx, query, playlistID); err != nil {
+		log.Printf("Ошибка нумерации элементов плейлиста: %v", err)
+		return fmt.Errorf("ошибка нумерации элементов плейлиста: %w", err)
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT count(*) FROM playlist_items WHERE playlist_id = $1`, playlistID); err != nil {
+		log.Printf("Ошибка подсчета элементов плейлиста: %v", err)
+		return fmt.Errorf("ошибка подсчета элементов плейлиста: %w", err)
+	}
+
+	if err := fn(tx, count); err != nil {
+		return err
+	}
+
+	after, err := loadPlaylistSnapshot(ctx, tx, playlistID)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntityPlaylist, playlistID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE playlists SET updated_at = now() WHERE id = $1`, playlistID); err != nil {
+		log.Printf("Ошибка обновления плейлиста: %v", err)
+		return fmt.Errorf("ошибка обновления плейлиста: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// loadPlaylistSnapshot считывает состояние плейлиста для журнала аудита
+// и блокирует его строку до конца транзакции
+func loadPlaylistSnapshot(ctx context.Context, tx *sqlx.Tx, playlistID int) (models.PlaylistSnapshot, error) {
+	var snapshot models.PlaylistSnapshot
+	query := `SELECT name, description FROM playlists WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRowxContext(ctx, query, playlistID).Scan(&snapshot.Name, &snapshot.Description)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PlaylistSnapshot{}, fmt.Errorf("плейлист %d: %w", playlistID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка блокировки плейлиста: %v", err)
+		return models.PlaylistSnapshot{}, fmt.Errorf("ошибка блокировки плейлиста: %w", err)
+	}
+
+	snapshot.SongIDs = []int{}
+	query = `SELECT song_id FROM playlist_items WHERE playlist_id = $1 ORDER BY position, id`
+	if err := tx.SelectContext(ctx, &snapshot.SongIDs, query, playlistID); err != nil {
+		log.Printf("Ошибка получения элементов плейлиста: %v", err)
+		return models.PlaylistSnapshot{}, fmt.Errorf("ошибка получения элементов плейлиста: %w", err)
+	}
+	return snapshot, nil
+}
@@ -2,13 +2,44 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"music_library/internal/models"
+	"music_library/internal/normalize"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// provenanceColumns - столбцы источников информации о песне для выборки в models.Song
+const provenanceColumns = `release_date_source AS "provenance.release_date",
+	text_source AS "provenance.text", link_source AS "provenance.link"`
+
+// songItemColumns - столбцы песни s с приставкой "song." для вложенного поля Song
+// (элементы плейлистов, избранное, история прослушиваний)
+const songItemColumns = `s.id AS "song.id", s."group" AS "song.group", s.song AS "song.song",
+	s.release_date AS "song.release_date", s.link AS "song.link",
+	s.enrichment_status AS "song.enrichment_status",
+	s.release_date_source AS "song.provenance.release_date",
+	s.text_source AS "song.provenance.text",
+	s.link_source AS "song.provenance.link"`
+
+// songStatsColumns - столбцы статистики песни из таблицы songs: средняя оценка и количество
+// оценок, прослушиваний и добавлений в избранное
+const songStatsColumns = `
+	COALESCE((SELECT round(avg(rating), 2) FROM song_ratings WHERE song_id = songs.id), 0)::float8 AS "stats.average_rating",
+	(SELECT count(*) FROM song_ratings WHERE song_id = songs.id) AS "stats.rating_count",
+	(SELECT count(*) FROM plays WHERE song_id = songs.id) AS "stats.play_count",
+	(SELECT count(*) FROM favorites WHERE song_id = songs.id) AS "stats.favorite_count"`
+
+// songStatsOrder сопоставляет сортировки списка песен по статистике с выражениями ORDER BY
+var songStatsOrder = map[string]string{
+	models.SongSortPopularity: `"stats.play_count" DESC, id`,
+	models.SongSortRating:     `"stats.average_rating" DESC, "stats.rating_count" DESC, id`,
+}
+
 // PostgresRepository реализует интерфейс SongDB для PostgreSQL
 type PostgresRepository struct {
 	db *sqlx.DB
@@ -19,84 +50,201 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// AddSong добавляет новую песню в базу данных
-func (r *PostgresRepository) AddSong(ctx context.Context, song models.Song) (int, error) {
+// AddSong добавляет новую песню в базу данных.
+// Если allowDuplicate равен false, уникальный индекс по нормализованным названиям не допускает дубликатов.
+func (r *PostgresRepository) AddSong(ctx context.Context, song models.Song, allowDuplicate bool) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertSong(ctx, tx, song, allowDuplicate)
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := loadSnapshot(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordRevision(ctx, tx, id, models.RevisionActionCreate, models.SongSnapshot{}, after); err != nil {
+		return 0, err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, id, models.AuditActionCreate, nil, after); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Песня добавлена, ID: %d", id)
+	return id, nil
+}
+
+// insertSong добавляет строку песни в рамках транзакции
+func insertSong(ctx context.Context, tx *sqlx.Tx, song models.Song, allowDuplicate bool) (int, error) {
 	query := `
-		INSERT INTO songs ("group", song, release_date, text, link)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO songs ("group", song, release_date, link, group_key, song_key, allow_duplicate,
+			release_date_source, text_source, link_source, enrichment_status, next_enrichment_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $11 = $12 THEN now() END)
 		RETURNING id
 	`
 
+	status := song.EnrichmentStatus
+	if status == "" {
+		status = models.EnrichmentEnriched
+	}
+
 	var id int
-	err := r.db.QueryRowxContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Link).Scan(&id)
+	err := tx.QueryRowxContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Link,
+		normalize.Key(song.Group), normalize.Key(song.Song), allowDuplicate,
+		song.Provenance.ReleaseDate, song.Provenance.Text, song.Provenance.Link,
+		status, models.EnrichmentPending).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("%s - %s: %w", song.Group, song.Song, models.ErrDuplicate)
+	}
 	if err != nil {
 		log.Printf("Ошибка добавления песни: %v", err)
 		return 0, fmt.Errorf("ошибка добавления песни: %w", err)
 	}
 
-	log.Printf("Песня добавлена, ID: %d", id)
 	return id, nil
 }
 
-// GetSongs получает список песен из базы данных с учетом фильтрации и пагинации
-func (r *PostgresRepository) GetSongs(ctx context.Context, limit, offset int, filter models.Song) ([]models.Song, error) {
+// GetSongs получает список песен из базы данных с учетом фильтрации и пагинации.
+// Если sort задает сортировку по статистике, песни возвращаются вместе со статистикой.
+func (r *PostgresRepository) GetSongs(ctx context.Context, limit, offset int, filter models.Song, sort string) ([]models.Song, error) {
+	order, sortByStats := songStatsOrder[sort]
+
 	// Базовый запрос
+	columns := `id, "group", song, release_date, link, enrichment_status, ` + provenanceColumns
+	if sortByStats {
+		columns += `, ` + songStatsColumns
+	}
 	query := `
-        SELECT id, "group", song, release_date, text, link
+        SELECT ` + columns + `
         FROM songs
         WHERE 1=1
     `
 
-	// Параметры для запроса
+	// Добавление условий фильтрации
+	conditions, args := songFilterConditions(filter)
+	query += conditions
+	argIndex := len(args) + 1
+
+	if sortByStats {
+		query += ` ORDER BY ` + order
+	}
+
+	// Добавление пагинации
+	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var songs []models.Song
+	err := r.db.SelectContext(ctx, &songs, query, args...)
+	if err != nil {
+		log.Printf("Ошибка получения песен: %v", err)
+		return nil, fmt.Errorf("ошибка получения песен: %w", err)
+	}
+
+	return songs, nil
+}
+
+// songFilterConditions возвращает условия фильтрации списка песен вида " AND ..." и их параметры,
+// параметры нумеруются с $1
+func songFilterConditions(filter models.Song) (string, []interface{}) {
+	var conditions string
 	args := []interface{}{}
 	argIndex := 1
 
-	// Добавление условий фильтрации
 	if filter.Group != "" {
-		query += fmt.Sprintf(` AND "group" ILIKE $%d`, argIndex)
+		conditions += fmt.Sprintf(` AND "group" ILIKE $%d`, argIndex)
 		args = append(args, "%"+filter.Group+"%")
 		argIndex++
 	}
 	if filter.Song != "" {
-		query += fmt.Sprintf(` AND song ILIKE $%d`, argIndex)
+		conditions += fmt.Sprintf(` AND song ILIKE $%d`, argIndex)
 		args = append(args, "%"+filter.Song+"%")
 		argIndex++
 	}
 	if filter.ReleaseDate != "" {
-		query += fmt.Sprintf(` AND release_date = $%d`, argIndex)
+		conditions += fmt.Sprintf(` AND release_date = $%d`, argIndex)
 		args = append(args, filter.ReleaseDate)
 		argIndex++
 	}
 
 	if filter.Link != "" {
-		query += fmt.Sprintf(` AND link = $%d`, argIndex)
+		conditions += fmt.Sprintf(` AND link = $%d`, argIndex)
 		args = append(args, filter.Link)
 		argIndex++
 	}
 
-	// Добавление пагинации
-	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	if filter.EnrichmentStatus != "" {
+		conditions += fmt.Sprintf(` AND enrichment_status = $%d`, argIndex)
+		args = append(args, filter.EnrichmentStatus)
+	}
+
+	return conditions, args
+}
+
+// FindSongsByGroupKey получает песни группы по нормализованному названию группы
+func (r *PostgresRepository) FindSongsByGroupKey(ctx context.Context, groupKey string) ([]models.Song, error) {
+	query := `
+		SELECT id, "group", song, release_date, link
+		FROM songs
+		WHERE group_key = $1
+		ORDER BY id
+	`
 
 	var songs []models.Song
-	err := r.db.SelectContext(ctx, &songs, query, args...)
-	if err != nil {
-		log.Printf("Ошибка получения песен: %v", err)
-		return nil, fmt.Errorf("ошибка получения песен: %w", err)
+	if err := r.db.SelectContext(ctx, &songs, query, groupKey); err != nil {
+		log.Printf("Ошибка поиска песен группы: %v", err)
+		return nil, fmt.Errorf("ошибка поиска песен группы: %w", err)
 	}
 
 	return songs, nil
 }
 
-// GetSongByID получает песню по ID
+// FindSongByKeys находит песню по нормализованным названиям группы и песни.
+// Если сохранено несколько дубликатов, возвращается песня с наименьшим ID.
+func (r *PostgresRepository) FindSongByKeys(ctx context.Context, groupKey, songKey string) (models.Song, error) {
+	query := `
+		SELECT id, "group", song, release_date, link, enrichment_status, ` + provenanceColumns + `
+		FROM songs
+		WHERE group_key = $1 AND song_key = $2
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var song models.Song
+	err := r.db.GetContext(ctx, &song, query, groupKey, songKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Song{}, fmt.Errorf("песня %q - %q: %w", groupKey, songKey, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка поиска песни по названиям: %v", err)
+		return models.Song{}, fmt.Errorf("ошибка поиска песни по названиям: %w", err)
+	}
+
+	return song, nil
+}
+
+// GetSongByID получает песню по ID вместе со статистикой
 func (r *PostgresRepository) GetSongByID(ctx context.Context, id int) (models.Song, error) {
 	query := `
-		SELECT id, "group", song, release_date, text, link
+		SELECT id, "group", song, release_date, link, enrichment_status, ` + provenanceColumns + `, ` + songStatsColumns + `
 		FROM songs
 		WHERE id = $1
 	`
 	var song models.Song
 	err := r.db.GetContext(ctx, &song, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Song{}, fmt.Errorf("песня с ID %d: %w", id, models.ErrNotFound)
+	}
 	if err != nil {
 		log.Printf("Ошибка получения песни по ID: %v", err)
 		return models.Song{}, fmt.Errorf("ошибка получения песни по ID: %w", err)
@@ -105,66 +253,197 @@ func (r *PostgresRepository) GetSongByID(ctx context.Context, id int) (models.So
 	return song, nil
 }
 
-// UpdateSong обновляет данные песни
+// UpdateSong обновляет данные песни и сохраняет ревизию в той же транзакции.
+// Источником измененных даты релиза и ссылки становится пользователь.
 func (r *PostgresRepository) UpdateSong(ctx context.Context, song models.Song) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSnapshot(ctx, tx, song.ID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE songs
-		SET "group" = $1, song = $2, release_date = $3, text = $4, link = $5
-		WHERE id = $6
+		SET "group" = $1, song = $2, release_date = $3, link = $4, group_key = $5, song_key = $6,
+			release_date_source = CASE WHEN release_date IS DISTINCT FROM $3 THEN $8 ELSE release_date_source END,
+			link_source = CASE WHEN link IS DISTINCT FROM $4 THEN $8 ELSE link_source END
+		WHERE id = $7
 	`
-	_, err := r.db.ExecContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Link, song.ID)
+	_, err = tx.ExecContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Link,
+		normalize.Key(song.Group), normalize.Key(song.Song), song.ID, models.SourceUser)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%s - %s: %w", song.Group, song.Song, models.ErrDuplicate)
+	}
 	if err != nil {
 		log.Printf("Ошибка обновления песни: %v", err)
 		return fmt.Errorf("ошибка обновления песни: %w", err)
 	}
 
+	after := before
+	after.Group = song.Group
+	after.Song = song.Song
+	after.ReleaseDate = song.ReleaseDate
+	after.Link = song.Link
+	if err := recordRevision(ctx, tx, song.ID, models.RevisionActionUpdate, before, after); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, song.ID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
 	log.Printf("Песня обновлена, ID: %d", song.ID)
 	return nil
 }
 
-// DeleteSong удаляет песню из базы данных
+// DeleteSong удаляет песню из базы данных и записывает ее последнее состояние в журнал аудита.
+// Удаление несуществующей песни не считается ошибкой.
 func (r *PostgresRepository) DeleteSong(ctx context.Context, id int) error {
-	query := `
-		DELETE FROM songs
-		WHERE id = $1
-	`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
 
-	_, err := r.db.ExecContext(ctx, query, id)
+	before, err := loadSnapshot(ctx, tx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM songs WHERE id = $1`, id); err != nil {
 		log.Printf("Ошибка удаления песни: %v", err)
 		return fmt.Errorf("ошибка удаления песни: %w", err)
 	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
 
 	log.Printf("Песня удалена, ID: %d", id)
 
 	return nil
 }
 
-// AddVerses добавляет куплеты для песни
-func (r *PostgresRepository) AddVerses(ctx context.Context, songID int, verses []models.Verse) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+// AddVerses добавляет куплеты для песни и сохраняет ревизию в той же транзакции.
+// Если source не пустой, он записывается как источник текста песни.
+func (r *PostgresRepository) AddVerses(ctx context.Context, songID int, verses []models.Verse, source string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		log.Printf("Ошибка начала транзакции: %v", err)
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
 	defer tx.Rollback()
 
-	for _, verse := range verses {
-		query := `
-			INSERT INTO verses (song_id, verse_number, text)
-			VALUES ($1, $2, $3)
-		`
-		_, err := tx.ExecContext(ctx, query, songID, verse.VerseNumber, verse.Text)
-		if err != nil {
-			log.Printf("Ошибка добавления куплета: %v", err)
-			return fmt.Errorf("ошибка добавления куплета: %w", err)
+	before, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertVerses(ctx, tx, songID, verses); err != nil {
+		return err
+	}
+	if source != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET text_source = $2 WHERE id = $1`, songID, source); err != nil {
+			log.Printf("Ошибка сохранения источника текста: %v", err)
+			return fmt.Errorf("ошибка сохранения источника текста: %w", err)
 		}
-		log.Printf("Куплет добавлен, SongID: %d, VerseNumber: %d", songID, verse.VerseNumber)
+	}
+
+	after, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+	if err := reanchorAnnotations(ctx, tx, songID, after.Verses); err != nil {
+		return err
+	}
+	if err := recordRevision(ctx, tx, songID, models.RevisionActionVerses, before, after); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntityVerse, songID, models.AuditActionCreate, nil, verses); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
+// UpdateVerse изменяет текст куплета песни, сохраняет ревизию и переносит привязки аннотаций
+// в той же транзакции. Текст песни после изменения считается введенным пользователем.
+func (r *PostgresRepository) UpdateVerse(ctx context.Context, songID, verseNumber int, text string) (models.Verse, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return models.Verse{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return models.Verse{}, err
+	}
+
+	var verse models.Verse
+	query := `
+		UPDATE verses
+		SET text = $3
+		WHERE song_id = $1 AND verse_number = $2
+		RETURNING id, song_id, verse_number, text
+	`
+	err = tx.GetContext(ctx, &verse, query, songID, verseNumber, text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Verse{}, fmt.Errorf("куплет %d песни %d: %w", verseNumber, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка изменения куплета: %v", err)
+		return models.Verse{}, fmt.Errorf("ошибка изменения куплета: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE songs SET text_source = $2 WHERE id = $1`, songID, models.SourceUser); err != nil {
+		log.Printf("Ошибка сохранения источника текста: %v", err)
+		return models.Verse{}, fmt.Errorf("ошибка сохранения источника текста: %w", err)
+	}
+
+	after, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return models.Verse{}, err
+	}
+	if err := reanchorAnnotations(ctx, tx, songID, after.Verses); err != nil {
+		return models.Verse{}, err
+	}
+	if err := recordRevision(ctx, tx, songID, models.RevisionActionVerses, before, after); err != nil {
+		return models.Verse{}, err
+	}
+	previous := verse
+	previous.Text = versesByNumber(before.Verses)[verseNumber]
+	if err := recordAudit(ctx, tx, models.AuditEntityVerse, songID, models.AuditActionUpdate, previous, verse); err != nil {
+		return models.Verse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return models.Verse{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Куплет изменен, SongID: %d, VerseNumber: %d", songID, verseNumber)
+	return verse, nil
+}
+
 // GetVersesBySongID получает куплеты для песни с пагинацией
 func (r *PostgresRepository) GetVersesBySongID(ctx context.Context, songID, limit, offset int) ([]models.Verse, error) {
 	query := `
@@ -183,3 +462,15 @@ func (r *PostgresRepository) GetVersesBySongID(ctx context.Context, songID, limi
 
 	return verses, nil
 }
+
+// isUniqueViolation проверяет, что ошибка вызвана нарушением уникального индекса
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation проверяет, что ошибка вызвана ссылкой на несуществующую строку
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
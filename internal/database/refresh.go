@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"slices"
+)
+
+// RefreshSong обновляет дату релиза, ссылку и куплеты песни по повторно полученной информации
+// и сохраняет ревизию в той же транзакции. Поля, заданные пользователем, и пустые значения
+// источника не изменяются. Если dryRun равен true, изменения только возвращаются, но не сохраняются.
+func (r *PostgresRepository) RefreshSong(ctx context.Context, songID int, details models.SongDetails, verses []models.Verse, dryRun bool) (models.RefreshResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return models.RefreshResult{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return models.RefreshResult{}, err
+	}
+
+	var sources models.DetailsProvenance
+	query := `SELECT release_date_source AS release_date, text_source AS text, link_source AS link FROM songs WHERE id = $1`
+	if err := tx.GetContext(ctx, &sources, query, songID); err != nil {
+		log.Printf("Ошибка получения источников информации о песне: %v", err)
+		return models.RefreshResult{}, fmt.Errorf("ошибка получения источников информации о песне: %w", err)
+	}
+
+	result := models.RefreshResult{SongID: songID, Group: before.Group, Song: before.Song}
+	after := before
+	provenance := sources
+
+	// refresh решает, заменить ли значение поля: пустые и совпадающие значения пропускаются,
+	// значения, заданные пользователем, сохраняются и попадают в список overridden
+	refresh := func(field, source string, changed bool) bool {
+		if !changed {
+			return false
+		}
+		if source == models.SourceUser {
+			result.Overridden = append(result.Overridden, field)
+			return false
+		}
+		return true
+	}
+
+	if refresh("release_date", sources.ReleaseDate, details.ReleaseDate != "" && details.ReleaseDate != before.ReleaseDate) {
+		after.ReleaseDate, provenance.ReleaseDate = details.ReleaseDate, details.Provenance.ReleaseDate
+	}
+	if refresh("link", sources.Link, details.Link != "" && details.Link != before.Link) {
+		after.Link, provenance.Link = details.Link, details.Provenance.Link
+	}
+	replaceVerses := refresh("verses", sources.Text, len(verses) > 0 && !sameVerseTexts(before.Verses, verses))
+	if replaceVerses {
+		after.Verses, provenance.Text = verses, details.Provenance.Text
+	}
+
+	result.Changes = diffSnapshots(before, after)
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	query = `
+		UPDATE songs
+		SET release_date = $2, link = $3,
+			release_date_source = $4, text_source = $5, link_source = $6
+		WHERE id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, songID, after.ReleaseDate, after.Link,
+		provenance.ReleaseDate, provenance.Text, provenance.Link)
+	if err != nil {
+		log.Printf("Ошибка обновления информации о песне: %v", err)
+		return models.RefreshResult{}, fmt.Errorf("ошибка обновления информации о песне: %w", err)
+	}
+
+	if replaceVerses {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM verses WHERE song_id = $1`, songID); err != nil {
+			log.Printf("Ошибка удаления куплетов при обновлении: %v", err)
+			return models.RefreshResult{}, fmt.Errorf("ошибка удаления куплетов при обновлении: %w", err)
+		}
+		if err := insertVerses(ctx, tx, songID, verses); err != nil {
+			return models.RefreshResult{}, err
+		}
+	}
+
+	after, err = loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return models.RefreshResult{}, err
+	}
+	if replaceVerses {
+		if err := reanchorAnnotations(ctx, tx, songID, after.Verses); err != nil {
+			return models.RefreshResult{}, err
+		}
+	}
+	if err := recordRevision(ctx, tx, songID, models.RevisionActionRefresh, before, after); err != nil {
+		return models.RefreshResult{}, err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, songID, models.AuditActionUpdate, before, after); err != nil {
+		return models.RefreshResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return models.RefreshResult{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Информация о песне обновлена, ID: %d", songID)
+	result.Applied = true
+	return result, nil
+}
+
+// sameVerseTexts проверяет, что тексты куплетов совпадают по порядку
+func sameVerseTexts(a, b []models.Verse) bool {
+	return slices.EqualFunc(a, b, func(x, y models.Verse) bool { return x.Text == y.Text })
+}
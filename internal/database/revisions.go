@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// revisionRow - строка таблицы song_revisions, снимок и отличия хранятся в JSONB
+type revisionRow struct {
+	ID        int       `db:"id"`
+	SongID    int       `db:"song_id"`
+	Revision  int       `db:"revision"`
+	Author    string    `db:"author"`
+	Action    string    `db:"action"`
+	Snapshot  []byte    `db:"snapshot"`
+	Diff      []byte    `db:"diff"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// toModel преобразует строку таблицы в модель ревизии
+func (row revisionRow) toModel() (models.SongRevision, error) {
+	rev := models.SongRevision{
+		ID:        row.ID,
+		SongID:    row.SongID,
+		Revision:  row.Revision,
+		Author:    row.Author,
+		Action:    row.Action,
+		CreatedAt: row.CreatedAt,
+	}
+	if err := json.Unmarshal(row.Snapshot, &rev.Snapshot); err != nil {
+		return models.SongRevision{}, fmt.Errorf("ошибка декодирования снимка ревизии: %w", err)
+	}
+	if err := json.Unmarshal(row.Diff, &rev.Diff); err != nil {
+		return models.SongRevision{}, fmt.Errorf("ошибка декодирования изменений ревизии: %w", err)
+	}
+	return rev, nil
+}
+
+// GetRevisions получает ревизии песни с пагинацией, начиная с самой новой
+func (r *PostgresRepository) GetRevisions(ctx context.Context, songID, limit, offset int) ([]models.SongRevision, error) {
+	query := `
+		SELECT id, song_id, revision, author, action, snapshot, diff, created_at
+		FROM song_revisions
+		WHERE song_id = $1
+		ORDER BY revision DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	var rows []revisionRow
+	if err := r.db.SelectContext(ctx, &rows, query, songID, limit, offset); err != nil {
+		log.Printf("Ошибка получения ревизий: %v", err)
+		return nil, fmt.Errorf("ошибка получения ревизий: %w", err)
+	}
+
+	revisions := make([]models.SongRevision, 0, len(rows))
+	for _, row := range rows {
+		rev, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision получает ревизию песни по ее номеру
+func (r *PostgresRepository) GetRevision(ctx context.Context, songID, revision int) (models.SongRevision, error) {
+	return getRevision(ctx, r.db, songID, revision)
+}
+
+// RevertToRevision восстанавливает песню и ее куплеты из снимка ревизии.
+// Откат сам сохраняется как новая ревизия.
+func (r *PostgresRepository) RevertToRevision(ctx context.Context, songID, revision int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+
+	target, err := getRevision(ctx, tx, songID, revision)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE songs
+		SET "group" = $1, song = $2, release_date = $3, link = $4, group_key = $5, song_key = $6
+		WHERE id = $7
+	`
+	snap := target.Snapshot
+	_, err = tx.ExecContext(ctx, query, snap.Group, snap.Song, snap.ReleaseDate, snap.Link,
+		normalize.Key(snap.Group), normalize.Key(snap.Song), songID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%s - %s: %w", snap.Group, snap.Song, models.ErrDuplicate)
+	}
+	if err != nil {
+		log.Printf("Ошибка отката песни: %v", err)
+		return fmt.Errorf("ошибка отката песни: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM verses WHERE song_id = $1`, songID); err != nil {
+		log.Printf("Ошибка удаления куплетов при откате: %v", err)
+		return fmt.Errorf("ошибка удаления куплетов при откате: %w", err)
+	}
+	if err := insertVerses(ctx, tx, songID, snap.Verses); err != nil {
+		return err
+	}
+
+	after, err := loadSnapshot(ctx, tx, songID)
+	if err != nil {
+		return err
+	}
+	if err := reanchorAnnotations(ctx, tx, songID, after.Verses); err != nil {
+		return err
+	}
+	if err := recordRevision(ctx, tx, songID, models.RevisionActionRevert, before, after); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySong, songID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Песня %d откачена к ревизии %d", songID, revision)
+	return nil
+}
+
+// getRevision получает ревизию песни в рамках соединения или транзакции
+func getRevision(ctx context.Context, q sqlx.QueryerContext, songID, revision int) (models.SongRevision, error) {
+	query := `
+		SELECT id, song_id, revision, author, action, snapshot, diff, created_at
+		FROM song_revisions
+		WHERE song_id = $1 AND revision = $2
+	`
+
+	var row revisionRow
+	err := sqlx.GetContext(ctx, q, &row, query, songID, revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SongRevision{}, fmt.Errorf("ревизия %d песни %d: %w", revision, songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения ревизии: %v", err)
+		return models.SongRevision{}, fmt.Errorf("ошибка получения ревизии: %w", err)
+	}
+
+	return row.toModel()
+}
+
+// loadSnapshot считывает текущее состояние песни и блокирует ее строку до конца транзакции
+func loadSnapshot(ctx context.Context, tx *sqlx.Tx, songID int) (models.SongSnapshot, error) {
+	var song models.Song
+	query := `
+		SELECT id, "group", song, release_date, link
+		FROM songs
+		WHERE id = $1
+		FOR UPDATE
+	`
+	err := tx.GetContext(ctx, &song, query, songID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SongSnapshot{}, fmt.Errorf("песня с ID %d: %w", songID, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения песни для ревизии: %v", err)
+		return models.SongSnapshot{}, fmt.Errorf("ошибка получения песни для ревизии: %w", err)
+	}
+
+	verses := []models.Verse{}
+	query = `
+		SELECT id, song_id, verse_number, text
+		FROM verses
+		WHERE song_id = $1
+		ORDER BY verse_number, id
+	`
+	if err := tx.SelectContext(ctx, &verses, query, songID); err != nil {
+		log.Printf("Ошибка получения куплетов для ревизии: %v", err)
+		return models.SongSnapshot{}, fmt.Errorf("ошибка получения куплетов для ревизии: %w", err)
+	}
+
+	return models.SongSnapshot{
+		Group:       song.Group,
+		Song:        song.Song,
+		ReleaseDate: song.ReleaseDate,
+		Link:        song.Link,
+		Verses:      verses,
+	}, nil
+}
+
+// insertVerses добавляет куплеты песни в рамках транзакции
+func insertVerses(ctx context.Context, tx *sqlx.Tx, songID int, verses []models.Verse) error {
+	query := `
+		INSERT INTO verses (song_id, verse_number, text)
+		VALUES ($1, $2, $3)
+	`
+	for _, verse := range verses {
+		if _, err := tx.ExecContext(ctx, query, songID, verse.VerseNumber, verse.Text); err != nil {
+			log.Printf("Ошибка добавления куплета: %v", err)
+			return fmt.Errorf("ошибка добавления куплета: %w", err)
+		}
+		log.Printf("Куплет добавлен, SongID: %d, VerseNumber: %d", songID, verse.VerseNumber)
+	}
+	return nil
+}
+
+// recordRevision сохраняет ревизию песни с ее снимком и отличиями от предыдущего состояния.
+// Автор изменения берется из контекста запроса.
+func recordRevision(ctx context.Context, tx *sqlx.Tx, songID int, action string, before, after models.SongSnapshot) error {
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования снимка ревизии: %w", err)
+	}
+	diff, err := json.Marshal(diffSnapshots(before, after))
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования изменений ревизии: %w", err)
+	}
+
+	query := `
+		INSERT INTO song_revisions (song_id, revision, author, action, snapshot, diff)
+		VALUES ($1, (SELECT COALESCE(MAX(revision), 0) + 1 FROM song_revisions WHERE song_id = $1), $2, $3, $4, $5)
+	`
+	if _, err := tx.ExecContext(ctx, query, songID, actor.FromContext(ctx), action, snapshot, diff); err != nil {
+		log.Printf("Ошибка сохранения ревизии: %v", err)
+		return fmt.Errorf("ошибка сохранения ревизии: %w", err)
+	}
+
+	return nil
+}
+
+// diffSnapshots возвращает список изменившихся полей песни и куплетов.
+// Куплеты сравниваются по номеру куплета.
+func diffSnapshots(before, after models.SongSnapshot) []models.FieldChange {
+	changes := []models.FieldChange{}
+	addChange := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, models.FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	addChange("group", before.Group, after.Group)
+	addChange("song", before.Song, after.Song)
+	addChange("release_date", before.ReleaseDate, after.ReleaseDate)
+	addChange("link", before.Link, after.Link)
+
+	oldVerses := versesByNumber(before.Verses)
+	newVerses := versesByNumber(after.Verses)
+	for _, verse := range before.Verses {
+		if _, ok := newVerses[verse.VerseNumber]; !ok {
+			addChange(verseField(verse.VerseNumber), verse.Text, "")
+		}
+	}
+	for _, verse := range after.Verses {
+		addChange(verseField(verse.VerseNumber), oldVerses[verse.VerseNumber], verse.Text)
+	}
+
+	return changes
+}
+
+// versesByNumber возвращает тексты куплетов по их номерам
+func versesByNumber(verses []models.Verse) map[int]string {
+	texts := make(map[int]string, len(verses))
+	for _, verse := range verses {
+		texts[verse.VerseNumber] = verse.Text
+	}
+	return texts
+}
+
+// verseField возвращает имя поля куплета для списка изменений
+func verseField(number int) string {
+	return "verses[" + strconv.Itoa(number) + "]"
+}
@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// releaseDateKey - выражение, приводящее дату выпуска DD.MM.YYYY к строке YYYYMMDD для сравнения
+// и сортировки. Для даты в другом формате выражение дает NULL.
+const releaseDateKey = `CASE WHEN release_date ~ '^[0-9]{2}\.[0-9]{2}\.[0-9]{4}$'
+	THEN substr(release_date, 7, 4) || substr(release_date, 4, 2) || substr(release_date, 1, 2) END`
+
+// smartListSortColumns сопоставляет поля сортировки умного списка с выражениями SQL
+var smartListSortColumns = map[string]string{
+	models.SmartSortID:          `id`,
+	models.SmartSortGroup:       `"group"`,
+	models.SmartSortSong:        `song`,
+	models.SmartSortReleaseDate: releaseDateKey,
+}
+
+// smartListRow - строка таблицы smart_lists, правило хранится в JSONB
+type smartListRow struct {
+	ID          int       `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Rules       []byte    `db:"rules"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// toModel преобразует строку таблицы в модель умного списка
+func (row smartListRow) toModel() (models.SmartList, error) {
+	list := models.SmartList{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Rules, &list.Rules); err != nil {
+		return models.SmartList{}, fmt.Errorf("ошибка декодирования правила умного списка: %w", err)
+	}
+	return list, nil
+}
+
+// CreateSmartList сохраняет умный список
+func (r *PostgresRepository) CreateSmartList(ctx context.Context, list models.SmartList) (int, error) {
+	rules, err := json.Marshal(list.Rules)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка кодирования правила умного списка: %w", err)
+	}
+
+	query := `
+		INSERT INTO smart_lists (name, description, rules)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRowxContext(ctx, query, list.Name, list.Description, rules).Scan(&id); err != nil {
+		log.Printf("Ошибка создания умного списка: %v", err)
+		return 0, fmt.Errorf("ошибка создания умного списка: %w", err)
+	}
+
+	after := models.SmartListSnapshot{Name: list.Name, Description: list.Description, Rules: list.Rules}
+	if err := recordAudit(ctx, tx, models.AuditEntitySmartList, id, models.AuditActionCreate, nil, after); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Умный список создан, ID: %d", id)
+	return id, nil
+}
+
+// GetSmartLists получает умные списки без песен с пагинацией
+func (r *PostgresRepository) GetSmartLists(ctx context.Context, limit, offset int) ([]models.SmartList, error) {
+	query := `
+		SELECT id, name, description, rules, created_at, updated_at
+		FROM smart_lists
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	var rows []smartListRow
+	if err := r.db.SelectContext(ctx, &rows, query, limit, offset); err != nil {
+		log.Printf("Ошибка получения умных списков: %v", err)
+		return nil, fmt.Errorf("ошибка получения умных списков: %w", err)
+	}
+
+	lists := make([]models.SmartList, 0, len(rows))
+	for _, row := range rows {
+		list, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, nil
+}
+
+// GetSmartList получает умный список без песен
+func (r *PostgresRepository) GetSmartList(ctx context.Context, id int) (models.SmartList, error) {
+	query := `SELECT id, name, description, rules, created_at, updated_at FROM smart_lists WHERE id = $1`
+
+	var row smartListRow
+	err := r.db.GetContext(ctx, &row, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SmartList{}, fmt.Errorf("умный список %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения умного списка: %v", err)
+		return models.SmartList{}, fmt.Errorf("ошибка получения умного списка: %w", err)
+	}
+
+	return row.toModel()
+}
+
+// UpdateSmartList изменяет название, описание и правило умного списка
+func (r *PostgresRepository) UpdateSmartList(ctx context.Context, list models.SmartList) error {
+	rules, err := json.Marshal(list.Rules)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования правила умного списка: %w", err)
+	}
+
+	query := `
+		UPDATE smart_lists
+		SET name = $2, description = $3, rules = $4, updated_at = now()
+		WHERE id = $1
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSmartListSnapshot(ctx, tx, list.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, list.ID, list.Name, list.Description, rules); err != nil {
+		log.Printf("Ошибка обновления умного списка: %v", err)
+		return fmt.Errorf("ошибка обновления умного списка: %w", err)
+	}
+
+	after := models.SmartListSnapshot{Name: list.Name, Description: list.Description, Rules: list.Rules}
+	if err := recordAudit(ctx, tx, models.AuditEntitySmartList, list.ID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// DeleteSmartList удаляет умный список
+func (r *PostgresRepository) DeleteSmartList(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSmartListSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM smart_lists WHERE id = $1`, id); err != nil {
+		log.Printf("Ошибка удаления умного списка: %v", err)
+		return fmt.Errorf("ошибка удаления умного списка: %w", err)
+	}
+	if err := recordAudit(ctx, tx, models.AuditEntitySmartList, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	log.Printf("Умный список удален, ID: %d", id)
+	return nil
+}
+
+// loadSmartListSnapshot считывает состояние умного списка для журнала аудита
+// и блокирует его строку до конца транзакции
+func loadSmartListSnapshot(ctx context.Context, tx *sqlx.Tx, id int) (models.SmartListSnapshot, error) {
+	var row smartListRow
+	err := tx.GetContext(ctx, &row, `SELECT id, name, description, rules FROM smart_lists WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SmartListSnapshot{}, fmt.Errorf("умный список %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка блокировки умного списка: %v", err)
+		return models.SmartListSnapshot{}, fmt.Errorf("ошибка блокировки умного списка: %w", err)
+	}
+
+	list, err := row.toModel()
+	if err != nil {
+		return models.SmartListSnapshot{}, err
+	}
+	return models.SmartListSnapshot{Name: list.Name, Description: list.Description, Rules: list.Rules}, nil
+}
+
+// GetSmartListSongs отбирает песни по правилу умного списка. Правило должно быть проверено
+// заранее. limit и offset задают страницу внутри списка, ограниченного rules.Limit.
+func (r *PostgresRepository) GetSmartListSongs(ctx context.Context, rules models.SmartListRules, limit, offset int) ([]models.Song, error) {
+	if rules.Limit > 0 {
+		if offset >= rules.Limit {
+			return []models.Song{}, nil
+		}
+		limit = min(limit, rules.Limit-offset)
+	}
+
+	conditions, args := songFilterConditions(rules.Filter())
+	query := `
+		SELECT id, "group", song, release_date, link, enrichment_status, ` + provenanceColumns + `
+		FROM songs
+		WHERE 1=1` + conditions
+
+	after, err := models.ReleaseDateBound(rules.ReleasedAfter, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+	if after != "" {
+		args = append(args, after)
+		query += fmt.Sprintf(` AND %s > $%d`, releaseDateKey, len(args))
+	}
+	before, err := models.ReleaseDateBound(rules.ReleasedBefore, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+	if before != "" {
+		args = append(args, before)
+		query += fmt.Sprintf(` AND %s < $%d`, releaseDateKey, len(args))
+	}
+
+	// Песни без даты выпуска при сортировке по дате идут в конце списка в любом направлении
+	order := smartListSortColumns[rules.Sort]
+	if order == "" {
+		order = smartListSortColumns[models.SmartSortID]
+	}
+	if rules.Desc {
+		order += " DESC NULLS LAST"
+	} else {
+		order += " NULLS LAST"
+	}
+	query += fmt.Sprintf(` ORDER BY %s, id LIMIT $%d OFFSET $%d`, order, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	songs := []models.Song{}
+	if err := r.db.SelectContext(ctx, &songs, query, args...); err != nil {
+		log.Printf("Ошибка отбора песен умного списка: %v", err)
+		return nil, fmt.Errorf("ошибка отбора песен умного списка: %w", err)
+	}
+
+	return songs, nil
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateUser сохраняет пользователя с уже захэшированным паролем
+func (r *PostgresRepository) CreateUser(ctx context.Context, user models.User) (int, error) {
+	query := `
+		INSERT INTO users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.QueryRowxContext(ctx, query, user.Username, user.PasswordHash, user.Role).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("%q: %w", user.Username, models.ErrUserExists)
+	}
+	if err != nil {
+		log.Printf("Ошибка создания пользователя: %v", err)
+		return 0, fmt.Errorf("ошибка создания пользователя: %w", err)
+	}
+
+	log.Printf("Пользователь создан, ID: %d", id)
+	return id, nil
+}
+
+// GetUserByUsername получает пользователя по имени без учета регистра
+func (r *PostgresRepository) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users WHERE lower(username) = lower($1)`
+
+	var user models.User
+	err := r.db.GetContext(ctx, &user, query, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("пользователь %q: %w", username, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения пользователя: %v", err)
+		return models.User{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByID получает пользователя по ID
+func (r *PostgresRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users WHERE id = $1`
+
+	var user models.User
+	err := r.db.GetContext(ctx, &user, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("пользователь %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения пользователя: %v", err)
+		return models.User{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateRefreshToken сохраняет хэш нового токена обновления пользователя
+func (r *PostgresRepository) CreateRefreshToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt); err != nil {
+		log.Printf("Ошибка сохранения токена обновления: %v", err)
+		return fmt.Errorf("ошибка сохранения токена обновления: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken отзывает предъявленный токен обновления и сохраняет вместо него новый.
+// Повторное предъявление уже отозванного токена означает, что он мог быть похищен:
+// в этом случае отзываются все токены пользователя.
+func (r *PostgresRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (models.User, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return models.User{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var token struct {
+		ID        int          `db:"id"`
+		UserID    int          `db:"user_id"`
+		ExpiresAt time.Time    `db:"expires_at"`
+		RevokedAt sql.NullTime `db:"revoked_at"`
+	}
+	query := `SELECT id, user_id, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+	err = tx.GetContext(ctx, &token, query, oldHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%w: неизвестный токен обновления", models.ErrUnauthorized)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения токена обновления: %v", err)
+		return models.User{}, fmt.Errorf("ошибка получения токена обновления: %w", err)
+	}
+
+	if token.RevokedAt.Valid {
+		query = `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+		if _, err := tx.ExecContext(ctx, query, token.UserID); err != nil {
+			log.Printf("Ошибка отзыва токенов обновления: %v", err)
+			return models.User{}, fmt.Errorf("ошибка отзыва токенов обновления: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("Ошибка фиксации транзакции: %v", err)
+			return models.User{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+		}
+		log.Printf("Повторно предъявлен отозванный токен обновления пользователя %d, все токены отозваны", token.UserID)
+		return models.User{}, fmt.Errorf("%w: токен обновления уже использован", models.ErrUnauthorized)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return models.User{}, fmt.Errorf("%w: срок действия токена обновления истек", models.ErrUnauthorized)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1`, token.ID); err != nil {
+		log.Printf("Ошибка отзыва токена обновления: %v", err)
+		return models.User{}, fmt.Errorf("ошибка отзыва токена обновления: %w", err)
+	}
+	query = `INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, token.UserID, newHash, expiresAt); err != nil {
+		log.Printf("Ошибка сохранения токена обновления: %v", err)
+		return models.User{}, fmt.Errorf("ошибка сохранения токена обновления: %w", err)
+	}
+
+	var user models.User
+	query = `SELECT id, username, password_hash, role, created_at FROM users WHERE id = $1`
+	if err := tx.GetContext(ctx, &user, query, token.UserID); err != nil {
+		log.Printf("Ошибка получения пользователя: %v", err)
+		return models.User{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return models.User{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return user, nil
+}
+
+// RevokeRefreshToken отзывает токен обновления. Неизвестный или уже отозванный токен не считается ошибкой.
+func (r *PostgresRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE token_hash = $1 AND revoked_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, tokenHash); err != nil {
+		log.Printf("Ошибка отзыва токена обновления: %v", err)
+		return fmt.Errorf("ошибка отзыва токена обновления: %w", err)
+	}
+	return nil
+}
+
+// GetUsers получает всех пользователей
+func (r *PostgresRepository) GetUsers(ctx context.Context) ([]models.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users ORDER BY id`
+
+	users := []models.User{}
+	if err := r.db.SelectContext(ctx, &users, query); err != nil {
+		log.Printf("Ошибка получения пользователей: %v", err)
+		return nil, fmt.Errorf("ошибка получения пользователей: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole изменяет роль пользователя. Последнего администратора нельзя понизить.
+func (r *PostgresRepository) SetUserRole(ctx context.Context, id int, role string) error {
+	return r.withLastAdminCheck(ctx, id, role != models.RoleAdmin, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, id); err != nil {
+			log.Printf("Ошибка изменения роли пользователя: %v", err)
+			return fmt.Errorf("ошибка изменения роли пользователя: %w", err)
+		}
+		log.Printf("Роль пользователя %d изменена на %s", id, role)
+		return nil
+	})
+}
+
+// DeleteUser удаляет пользователя вместе с его токенами обновления.
+// Последнего администратора удалить нельзя.
+func (r *PostgresRepository) DeleteUser(ctx context.Context, id int) error {
+	return r.withLastAdminCheck(ctx, id, true, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+			log.Printf("Ошибка удаления пользователя: %v", err)
+			return fmt.Errorf("ошибка удаления пользователя: %w", err)
+		}
+		log.Printf("Пользователь %d удален", id)
+		return nil
+	})
+}
+
+// withLastAdminCheck выполняет fn в транзакции, если пользователь существует. Если demote истинно,
+// а пользователь - единственный администратор, изменение отклоняется. Строки администраторов
+// блокируются, чтобы два встречных запроса не лишили друг друга прав одновременно.
+func (r *PostgresRepository) withLastAdminCheck(ctx context.Context, id int, demote bool, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var admins []int
+	query := `SELECT id FROM users WHERE role = $1 ORDER BY id FOR UPDATE`
+	if err := tx.SelectContext(ctx, &admins, query, models.RoleAdmin); err != nil {
+		log.Printf("Ошибка получения администраторов: %v", err)
+		return fmt.Errorf("ошибка получения администраторов: %w", err)
+	}
+
+	var role string
+	err = tx.GetContext(ctx, &role, `SELECT role FROM users WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("пользователь %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения пользователя: %v", err)
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	if demote && role == models.RoleAdmin && len(admins) == 1 {
+		return fmt.Errorf("%w: пользователь %d - единственный администратор", models.ErrInvalidInput, id)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка фиксации транзакции: %v", err)
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package exporter записывает песни в JSON, NDJSON, CSV и плейлисты M3U8 и XSPF по одной,
+// не накапливая их в памяти.
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"music_library/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Форматы выгрузки
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatM3U8   = "m3u8"
+	FormatXSPF   = "xspf"
+)
+
+// Writer записывает песни по одной. Close дописывает окончание файла.
+type Writer interface {
+	Write(song models.Song) error
+	Close() error
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatM3U8:
+		return "audio/x-mpegurl; charset=utf-8"
+	case FormatXSPF:
+		return "application/xspf+xml"
+	default:
+		return "application/json"
+	}
+}
+
+// NewWriter создает Writer для формата format
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	return NewTitledWriter(w, format, "")
+}
+
+// NewTitledWriter создает Writer для формата format. Название title записывается
+// в заголовок плейлистов M3U8 и XSPF, остальные форматы его не используют.
+func NewTitledWriter(w io.Writer, format, title string) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w, encoder: json.NewEncoder(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatM3U8:
+		return &m3uWriter{w: w, title: title}, nil
+	case FormatXSPF:
+		return &xspfWriter{w: w, title: title}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат выгрузки: %q, ожидается json, ndjson, csv, m3u8 или xspf", format)
+	}
+}
+
+// jsonWriter записывает JSON-массив песен
+type jsonWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+// Write записывает элемент массива
+func (j *jsonWriter) Write(song models.Song) error {
+	sep := ","
+	if j.count == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	j.count++
+	return j.encoder.Encode(song)
+}
+
+// Close закрывает массив, для пустой выгрузки записывается пустой массив
+func (j *jsonWriter) Close() error {
+	end := "]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// ndjsonWriter записывает по одной песне на строку
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+// Write записывает строку с песней
+func (n *ndjsonWriter) Write(song models.Song) error {
+	return n.encoder.Encode(song)
+}
+
+// Close ничего не делает: у NDJSON нет окончания
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvHeader - столбцы выгрузки CSV. Файл можно загрузить обратно через импорт.
+var csvHeader = []string{"id", "group", "song", "release_date", "link", "lyrics"}
+
+// csvWriter записывает песни в CSV, куплеты объединяются в текст через пустую строку
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// Write записывает строку с песней, перед первой строкой записывается заголовок
+func (c *csvWriter) Write(song models.Song) error {
+	if !c.header {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	verses := make([]string, len(song.Verses))
+	for i, verse := range song.Verses {
+		verses[i] = verse.Text
+	}
+	record := []string{strconv.Itoa(song.ID), song.Group, song.Song, song.ReleaseDate, song.Link, strings.Join(verses, "\n\n")}
+	return c.w.Write(record)
+}
+
+// Close записывает заголовок, если песен не было, и сбрасывает буфер
+func (c *csvWriter) Close() error {
+	if !c.header {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// m3uWriter записывает расширенный плейлист M3U в UTF-8. Адресом записи служит ссылка песни,
+// песни без ссылки пропускаются: запись M3U без адреса недопустима.
+type m3uWriter struct {
+	w      io.Writer
+	title  string
+	header bool
+}
+
+// writeHeader записывает заголовок плейлиста, если он еще не записан
+func (m *m3uWriter) writeHeader() error {
+	if m.header {
+		return nil
+	}
+	m.header = true
+	header := "#EXTM3U\n"
+	if m.title != "" {
+		header += "#PLAYLIST:" + oneLine(m.title) + "\n"
+	}
+	_, err := io.WriteString(m.w, header)
+	return err
+}
+
+// Write записывает песню как строку #EXTINF с названием и строку с адресом
+func (m *m3uWriter) Write(song models.Song) error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+	if song.Link == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(m.w, "#EXTINF:-1,%s - %s\n%s\n", oneLine(song.Group), oneLine(song.Song), oneLine(song.Link))
+	return err
+}
+
+// Close записывает заголовок, если песен не было
+func (m *m3uWriter) Close() error {
+	return m.writeHeader()
+}
+
+// oneLine заменяет переводы строк пробелами: в M3U каждая запись занимает одну строку
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// xspfTrack - элемент track плейлиста XSPF
+type xspfTrack struct {
+	XMLName  xml.Name `xml:"track"`
+	Location string   `xml:"location,omitempty"`
+	Creator  string   `xml:"creator"`
+	Title    string   `xml:"title"`
+}
+
+// xspfWriter записывает плейлист XSPF. Песни без ссылки записываются без адреса,
+// проигрыватель может найти их по исполнителю и названию.
+type xspfWriter struct {
+	w      io.Writer
+	title  string
+	header bool
+}
+
+// writeHeader записывает начало документа до списка треков, если оно еще не записано
+func (x *xspfWriter) writeHeader() error {
+	if x.header {
+		return nil
+	}
+	x.header = true
+	header := xml.Header + "<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\">\n"
+	if x.title != "" {
+		var title strings.Builder
+		if err := xml.EscapeText(&title, []byte(x.title)); err != nil {
+			return err
+		}
+		header += "  <title>" + title.String() + "</title>\n"
+	}
+	header += "  <trackList>\n"
+	_, err := io.WriteString(x.w, header)
+	return err
+}
+
+// Write записывает элемент track
+func (x *xspfWriter) Write(song models.Song) error {
+	if err := x.writeHeader(); err != nil {
+		return err
+	}
+	track, err := xml.MarshalIndent(xspfTrack{Location: song.Link, Creator: song.Group, Title: song.Song}, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(x.w, "%s\n", track)
+	return err
+}
+
+// Close закрывает список треков и документ
+func (x *xspfWriter) Close() error {
+	if err := x.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "  </trackList>\n</playlist>\n")
+	return err
+}
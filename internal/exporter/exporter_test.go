@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// songs - песни для выгрузки: у второй нет ссылки, в названии третьей есть перевод строки
+var songs = []models.Song{
+	{ID: 1, Group: "Muse", Song: "Hysteria", Link: "https://example.com/1"},
+	{ID: 2, Group: "Muse", Song: "Uprising"},
+	{ID: 3, Group: "Muse & <friends>", Song: "Star\nlight", Link: "https://example.com/3?a=1&b=2"},
+}
+
+// export выгружает песни в формате format и возвращает результат
+func export(t *testing.T, format, title string, songs []models.Song) string {
+	t.Helper()
+	var out strings.Builder
+	w, err := NewTitledWriter(&out, format, title)
+	if err != nil {
+		t.Fatalf("ошибка создания Writer: %v", err)
+	}
+	for _, song := range songs {
+		if err := w.Write(song); err != nil {
+			t.Fatalf("ошибка записи песни %d: %v", song.ID, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("ошибка закрытия Writer: %v", err)
+	}
+	return out.String()
+}
+
+func TestWriterOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		title  string
+		songs  []models.Song
+		want   string
+	}{
+		{
+			name:   "M3U8 пропускает песни без ссылки",
+			format: FormatM3U8,
+			title:  "Рок\nи не только",
+			songs:  songs,
+			want: "#EXTM3U\n" +
+				"#PLAYLIST:Рок и не только\n" +
+				"#EXTINF:-1,Muse - Hysteria\nhttps://example.com/1\n" +
+				"#EXTINF:-1,Muse & <friends> - Star light\nhttps://example.com/3?a=1&b=2\n",
+		},
+		{
+			name:   "пустой M3U8",
+			format: FormatM3U8,
+			want:   "#EXTM3U\n",
+		},
+		{
+			name:   "пустой XSPF",
+			format: FormatXSPF,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<playlist version="1" xmlns="http://xspf.org/ns/0/">` + "\n" +
+				"  <trackList>\n  </trackList>\n</playlist>\n",
+		},
+		{
+			name:   "пустой JSON",
+			format: FormatJSON,
+			want:   "[]\n",
+		},
+		{
+			name:   "пустой CSV",
+			format: FormatCSV,
+			want:   "id,group,song,release_date,link,lyrics\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := export(t, tt.format, tt.title, tt.songs); got != tt.want {
+				t.Errorf("выгрузка:\n%s\nожидалось:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Выгруженный плейлист читается импортом плейлистов с теми же исполнителями, названиями и адресами
+func TestPlaylistRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   []models.PlaylistEntry
+	}{
+		{
+			name:   "m3u8",
+			format: FormatM3U8,
+			want: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Hysteria", Location: "https://example.com/1"},
+				{Index: 2, Artist: "Muse & <friends>", Title: "Star light", Location: "https://example.com/3?a=1&b=2"},
+			},
+		},
+		{
+			name:   "xspf",
+			format: FormatXSPF,
+			want: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Hysteria", Location: "https://example.com/1"},
+				{Index: 2, Artist: "Muse", Title: "Uprising"},
+				{Index: 3, Artist: "Muse & <friends>", Title: "Star\nlight", Location: "https://example.com/3?a=1&b=2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := export(t, tt.format, "Избранное & <новое>", songs)
+			title, entries, err := importer.ReadPlaylist(strings.NewReader(out), tt.format)
+			if err != nil {
+				t.Fatalf("ошибка чтения выгрузки: %v\n%s", err, out)
+			}
+			if title != "Избранное & <новое>" {
+				t.Errorf("название %q, ожидалось %q", title, "Избранное & <новое>")
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("записи %+v, ожидались %+v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter(&strings.Builder{}, "pls"); err == nil {
+		t.Fatal("ожидалась ошибка для неизвестного формата")
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// AnnotationHandler обрабатывает запросы к аннотациям к тексту песен
+type AnnotationHandler struct {
+	annotations service.AnnotationService
+}
+
+// NewAnnotationHandler создает новый обработчик аннотаций
+func NewAnnotationHandler(annotations service.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{annotations: annotations}
+}
+
+// GetAnnotations обрабатывает GET-запрос на получение аннотаций песни.
+// @Summary Получить аннотации песни
+// @Description Возвращает аннотации к фрагментам текста песни в порядке добавления, ответы вложены в replies.
+// @Description Привязка задает номер куплета и диапазон символов [start, end) его текста. После изменения текста
+// @Description привязка переносится на найденный фрагмент; если фрагмент не найден, аннотация помечается orphaned.
+// @Tags annotations
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {array} models.Annotation "Аннотации с ответами"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка получения аннотаций"
+// @Router /songs/{id}/annotations [get]
+func (h *AnnotationHandler) GetAnnotations(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	annotations, err := h.annotations.GetAnnotations(r.Context(), songID)
+	if err != nil {
+		renderAnnotationError(w, err, "ошибка получения аннотаций")
+		return
+	}
+
+	render.JSON(w, r, annotations)
+}
+
+// CreateAnnotation обрабатывает POST-запрос на добавление аннотации.
+// @Summary Добавить аннотацию
+// @Description Добавляет аннотацию к диапазону символов [start, end) текста куплета. Позиции считаются в символах, а не в байтах.
+// @Tags annotations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param annotation body models.AnnotationRequest true "Куплет, диапазон символов и текст аннотации"
+// @Success 201 {object} models.Annotation "Добавленная аннотация"
+// @Failure 400 {string} string "Неверный ID, формат JSON, диапазон или пустой текст"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка добавления аннотации"
+// @Router /songs/{id}/annotations [post]
+func (h *AnnotationHandler) CreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	annotation, err := h.annotations.CreateAnnotation(r.Context(), songID, req)
+	if err != nil {
+		renderAnnotationError(w, err, "ошибка добавления аннотации")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, annotation)
+}
+
+// CreateReply обрабатывает POST-запрос на ответ к аннотации.
+// @Summary Ответить на аннотацию
+// @Description Добавляет ответ на аннотацию или на другой ответ той же песни.
+// @Tags annotations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param aid path int true "ID аннотации или ответа"
+// @Param reply body models.ReplyRequest true "Текст ответа"
+// @Success 201 {object} models.Annotation "Добавленный ответ"
+// @Failure 400 {string} string "Неверный ID, формат JSON или пустой текст"
+// @Failure 404 {string} string "Аннотация не найдена"
+// @Failure 500 {string} string "Ошибка добавления ответа"
+// @Router /songs/{id}/annotations/{aid}/replies [post]
+func (h *AnnotationHandler) CreateReply(w http.ResponseWriter, r *http.Request) {
+	songID, parentID, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	reply, err := h.annotations.CreateReply(r.Context(), songID, parentID, req)
+	if err != nil {
+		renderAnnotationError(w, err, "ошибка добавления ответа")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, reply)
+}
+
+// UpdateAnnotation обрабатывает PUT-запрос на изменение аннотации.
+// @Summary Изменить аннотацию
+// @Description Изменяет текст аннотации или ответа. Если указан verse_number, аннотация привязывается
+// @Description к новому диапазону символов [start, end) и перестает считаться осиротевшей.
+// @Tags annotations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param aid path int true "ID аннотации или ответа"
+// @Param annotation body models.AnnotationUpdate true "Текст и, при необходимости, новая привязка"
+// @Success 200 {object} models.Annotation "Измененная аннотация"
+// @Failure 400 {string} string "Неверный ID, формат JSON, диапазон или пустой текст"
+// @Failure 404 {string} string "Аннотация не найдена"
+// @Failure 500 {string} string "Ошибка изменения аннотации"
+// @Router /songs/{id}/annotations/{aid} [put]
+func (h *AnnotationHandler) UpdateAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, id, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var upd models.AnnotationUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	annotation, err := h.annotations.UpdateAnnotation(r.Context(), songID, id, upd)
+	if err != nil {
+		renderAnnotationError(w, err, "ошибка изменения аннотации")
+		return
+	}
+
+	render.JSON(w, r, annotation)
+}
+
+// DeleteAnnotation обрабатывает DELETE-запрос на удаление аннотации.
+// @Summary Удалить аннотацию
+// @Description Удаляет аннотацию или ответ вместе со всеми ответами на них.
+// @Tags annotations
+// @Param id path int true "ID песни"
+// @Param aid path int true "ID аннотации или ответа"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Аннотация не найдена"
+// @Failure 500 {string} string "Ошибка удаления аннотации"
+// @Router /songs/{id}/annotations/{aid} [delete]
+func (h *AnnotationHandler) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, id, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.annotations.DeleteAnnotation(r.Context(), songID, id); err != nil {
+		renderAnnotationError(w, err, "ошибка удаления аннотации")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// annotationIDs получает ID песни и аннотации из пути запроса.
+// Если ID неверный, отправляет ответ 400 и возвращает false.
+func annotationIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "aid"))
+	if err != nil {
+		http.Error(w, "неверный ID аннотации", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return songID, id, true
+}
+
+// renderAnnotationError отправляет ответ с ошибкой сервиса аннотаций
+func renderAnnotationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+	}
+}
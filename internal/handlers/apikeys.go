@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// CreateAPIKey обрабатывает POST-запрос на создание ключа API.
+// @Summary Создать ключ API
+// @Description Создает ключ API для сервисов с областями действия read (права роли viewer) и write (создание и изменение, как у роли editor).
+// @Description Ключ передается в заголовке X-API-Key. Открытый ключ возвращается только в этом ответе, хранится лишь его хэш.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyRequest true "Название, области действия и срок действия"
+// @Success 201 {object} models.CreatedAPIKey "Созданный ключ"
+// @Failure 400 {string} string "Неверный формат JSON или параметры ключа"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка создания ключа API"
+// @Router /admin/api-keys [post]
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.auth.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		renderAuthError(w, err, "ошибка создания ключа API")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, key)
+}
+
+// GetAPIKeys обрабатывает GET-запрос на получение ключей API.
+// @Summary Получить ключи API
+// @Description Возвращает все ключи API, включая отозванные, с началом ключа, областями действия, сроком и временем последнего использования.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.APIKey "Ключи API"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка получения ключей API"
+// @Router /admin/api-keys [get]
+func (h *AuthHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.auth.GetAPIKeys(r.Context())
+	if err != nil {
+		renderAuthError(w, err, "ошибка получения ключей API")
+		return
+	}
+
+	render.JSON(w, r, keys)
+}
+
+// RevokeAPIKey обрабатывает DELETE-запрос на отзыв ключа API.
+// @Summary Отозвать ключ API
+// @Description Отзывает ключ API, запросы с ним сразу перестают приниматься. Запись о ключе сохраняется.
+// @Tags admin
+// @Param id path int true "ID ключа API"
+// @Success 200 {object} map[string]string "Статус отзыва"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 404 {string} string "Действующий ключ не найден"
+// @Failure 500 {string} string "Ошибка отзыва ключа API"
+// @Router /admin/api-keys/{id} [delete]
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.auth.RevokeAPIKey(r.Context(), id); err != nil {
+		renderAuthError(w, err, "ошибка отзыва ключа API")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
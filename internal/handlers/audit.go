@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// AuditHandler обрабатывает запросы к журналу аудита
+type AuditHandler struct {
+	audit service.AuditService
+}
+
+// NewAuditHandler создает новый обработчик журнала аудита
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{audit: auditService}
+}
+
+// GetAuditLog обрабатывает GET-запрос на получение журнала аудита.
+// @Summary Журнал аудита
+// @Description Возвращает записи о создании, изменении и удалении песен, куплетов и плейлистов, новые первыми.
+// @Description Запись содержит инициатора, идентификатор запроса, IP-адрес клиента, время и состояние сущности до и после изменения.
+// @Description Для куплетов entity_id - ID песни. Журнал только дополняется, записи нельзя изменить или удалить.
+// @Tags audit
+// @Produce json
+// @Param entity query string false "Сущность: song, verse, playlist или smart_list"
+// @Param entity_id query int false "ID сущности"
+// @Param actor query string false "Инициатор изменения"
+// @Param from query string false "Начало периода (RFC 3339 или YYYY-MM-DD), включительно"
+// @Param to query string false "Конец периода (RFC 3339 или YYYY-MM-DD - до конца дня), включительно"
+// @Param limit query int false "Количество записей на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {array} models.AuditEntry "Записи журнала"
+// @Failure 400 {string} string "Неверные параметры отбора или пагинации"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка получения журнала аудита"
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset := paginationFromContext(r)
+
+	entries, err := h.audit.GetAuditLog(r.Context(), filter, limit, offset)
+	if errors.Is(err, models.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения журнала аудита: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, entries)
+}
+
+// auditFilterFromRequest извлекает условия отбора журнала аудита из параметров запроса
+func auditFilterFromRequest(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+	}
+
+	if value := query.Get("entity_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return models.AuditFilter{}, fmt.Errorf("неверный параметр entity_id")
+		}
+		filter.EntityID = id
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(query.Get("from"), false); err != nil {
+		return models.AuditFilter{}, fmt.Errorf("неверный параметр from: %w", err)
+	}
+	if filter.To, err = parseAuditTime(query.Get("to"), true); err != nil {
+		return models.AuditFilter{}, fmt.Errorf("неверный параметр to: %w", err)
+	}
+	return filter, nil
+}
+
+// parseAuditTime разбирает границу периода в формате RFC 3339 или YYYY-MM-DD.
+// Дата без времени означает начало дня, а для конца периода (endOfDay) - его конец.
+func parseAuditTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("ожидается RFC 3339 или YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return &t, nil
+}
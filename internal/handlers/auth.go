@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music_library/internal/actor"
+	"music_library/internal/auth"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// AuthHandler обрабатывает вход, обновление токенов и проверяет токены доступа
+type AuthHandler struct {
+	auth     service.AuthService
+	required bool
+}
+
+// NewAuthHandler создает новый обработчик аутентификации. required запрещает
+// запросы без токена доступа к маршрутам, защищенным Authenticate.
+func NewAuthHandler(authService service.AuthService, required bool) *AuthHandler {
+	return &AuthHandler{auth: authService, required: required}
+}
+
+// Login обрабатывает POST-запрос на вход.
+// @Summary Войти
+// @Description Проверяет имя пользователя и пароль и выдает токен доступа JWT и токен обновления.
+// @Description Токен доступа передается в заголовке Authorization: Bearer <токен>.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Имя пользователя и пароль"
+// @Success 200 {object} models.TokenPair "Токены"
+// @Failure 400 {string} string "Неверный формат JSON"
+// @Failure 401 {string} string "Неверное имя пользователя или пароль"
+// @Failure 500 {string} string "Ошибка входа"
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.auth.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		renderAuthError(w, err, "ошибка входа")
+		return
+	}
+
+	render.JSON(w, r, tokens)
+}
+
+// Refresh обрабатывает POST-запрос на обновление токенов.
+// @Summary Обновить токены
+// @Description Выдает новый токен доступа и новый токен обновления, предъявленный токен обновления отзывается.
+// @Description Повторное предъявление отозванного токена отзывает все токены пользователя.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Токен обновления"
+// @Success 200 {object} models.TokenPair "Токены"
+// @Failure 400 {string} string "Неверный формат JSON"
+// @Failure 401 {string} string "Токен обновления недействителен"
+// @Failure 500 {string} string "Ошибка обновления токенов"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.auth.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		renderAuthError(w, err, "ошибка обновления токенов")
+		return
+	}
+
+	render.JSON(w, r, tokens)
+}
+
+// Logout обрабатывает POST-запрос на выход.
+// @Summary Выйти
+// @Description Отзывает токен обновления. Выданный токен доступа действует до истечения срока.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Токен обновления"
+// @Success 200 {object} map[string]string "Статус выхода"
+// @Failure 400 {string} string "Неверный формат JSON"
+// @Failure 500 {string} string "Ошибка выхода"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.auth.Logout(r.Context(), req.RefreshToken); err != nil {
+		renderAuthError(w, err, "ошибка выхода")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// Me обрабатывает GET-запрос на получение вошедшего пользователя.
+// @Summary Текущий пользователь
+// @Description Возвращает пользователя, которому выдан токен доступа.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.User "Пользователь"
+// @Failure 401 {string} string "Требуется вход"
+// @Router /auth/me [get]
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		unauthorized(w, "требуется вход")
+		return
+	}
+	render.JSON(w, r, user)
+}
+
+// Authenticate middleware проверяет ключ API из заголовка X-API-Key или токен доступа из заголовка
+// Authorization и сохраняет в контексте ключ или пользователя. Имя пользователя (или ключа с приставкой
+// "api-key:") становится инициатором изменений, без них - actor.Anonymous. Недействительные ключ и токен
+// отклоняются всегда, запрос без них - если вход обязателен.
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			h.authenticateAPIKey(w, r, next, key)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if h.required {
+				unauthorized(w, "требуется вход")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			unauthorized(w, "ожидается заголовок Authorization: Bearer <токен>")
+			return
+		}
+
+		user, err := h.auth.Authenticate(r.Context(), strings.TrimSpace(token))
+		if errors.Is(err, models.ErrUnauthorized) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ошибка проверки токена: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		ctx := auth.WithUser(r.Context(), user)
+		ctx = actor.WithName(ctx, user.Username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticateAPIKey проверяет ключ API. Его области действия проверяет Authorize.
+func (h *AuthHandler) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	apiKey, err := h.auth.AuthenticateAPIKey(r.Context(), key)
+	if errors.Is(err, models.ErrUnauthorized) {
+		unauthorized(w, err.Error())
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка проверки ключа API: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := auth.WithAPIKey(r.Context(), apiKey)
+	ctx = actor.WithName(ctx, "api-key:"+apiKey.Name)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Authorize возвращает middleware, пропускающий только запросы с правом perm. Права пользователя
+// определяются его ролью, права ключа API - областями действия. Отказ возвращается со статусом 403
+// и телом application/problem+json. Если вход не обязателен, анонимным запросам разрешены только
+// чтение и изменение, для удаления и управления доступом всегда требуется вход. Применяется после Authenticate.
+func (h *AuthHandler) Authorize(perm models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey, ok := auth.APIKeyFromContext(r.Context()); ok {
+				if !apiKey.Can(perm) {
+					forbidden(w, r, perm, fmt.Sprintf("областям действия ключа API %q не выдано право %s", apiKey.Name, perm))
+					return
+				}
+			} else if user, ok := auth.UserFromContext(r.Context()); ok {
+				if !user.Can(perm) {
+					forbidden(w, r, perm, fmt.Sprintf("роли %s не выдано право %s", user.Role, perm))
+					return
+				}
+			} else if h.required || !models.AnonymousCan(perm) {
+				unauthorized(w, "требуется вход")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUser middleware пропускает только запросы вошедших пользователей.
+// Запросы с ключом API отклоняются: ключи не могут управлять доступом.
+func (h *AuthHandler) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.APIKeyFromContext(r.Context()); ok {
+			http.Error(w, "действие недоступно для ключа API", http.StatusForbidden)
+			return
+		}
+		if _, ok := auth.UserFromContext(r.Context()); !ok {
+			unauthorized(w, "требуется вход")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// unauthorized отвечает статусом 401 с указанием схемы аутентификации
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="music_library"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// renderAuthError отправляет ошибку сервиса аутентификации с подходящим статусом
+func renderAuthError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrUnauthorized):
+		unauthorized(w, err.Error())
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrUserExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"music_library/internal/provider"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// DiagnosticsHandler отдает служебную информацию о состоянии внешних зависимостей
+type DiagnosticsHandler struct {
+	details provider.DetailsProvider
+}
+
+// NewDiagnosticsHandler создает новый обработчик диагностики
+func NewDiagnosticsHandler(details provider.DetailsProvider) *DiagnosticsHandler {
+	return &DiagnosticsHandler{details: details}
+}
+
+// GetProviders обрабатывает GET-запрос на получение состояния источников информации о песнях.
+// @Summary Состояние источников информации
+// @Description Возвращает источники информации о песнях и состояние размыкателя внешнего API (closed, open, half-open).
+// @Tags diagnostics
+// @Produce json
+// @Success 200 {array} provider.ProviderStatus "Состояние источников"
+// @Router /diagnostics/providers [get]
+func (h *DiagnosticsHandler) GetProviders(w http.ResponseWriter, r *http.Request) {
+	statuses := []provider.ProviderStatus{}
+	if reporter, ok := h.details.(provider.StatusReporter); ok {
+		statuses = reporter.Status()
+	}
+	render.JSON(w, r, statuses)
+}
+
+// GetCache обрабатывает GET-запрос на получение счетчиков кэша информации о песнях.
+// @Summary Счетчики кэша информации
+// @Description Возвращает количество попаданий в кэш (в том числе ответов "песня не найдена"), промахов и ошибок кэша с момента запуска.
+// @Tags diagnostics
+// @Produce json
+// @Success 200 {object} provider.CacheStats "Счетчики кэша"
+// @Failure 404 {string} string "Кэш отключен"
+// @Router /diagnostics/cache [get]
+func (h *DiagnosticsHandler) GetCache(w http.ResponseWriter, r *http.Request) {
+	cached, ok := h.details.(interface{ CacheStats() provider.CacheStats })
+	if !ok {
+		http.Error(w, "Кэш информации о песнях отключен", http.StatusNotFound)
+		return
+	}
+	render.JSON(w, r, cached.CacheStats())
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"music_library/internal/exporter"
+	"music_library/internal/models"
+	"net/http"
+	"time"
+)
+
+// ExportSongs обрабатывает GET-запрос на выгрузку библиотеки.
+// @Summary Выгрузить песни
+// @Description Выгружает песни с куплетами в формате JSON (массив), NDJSON или CSV. Песни передаются по мере чтения из базы данных
+// @Description в порядке ID, поддерживаются те же фильтры, что и у GET /songs. Ответ отдается как файл для загрузки.
+// @Description Форматы m3u8 и xspf выгружают песни как плейлист для проигрывателей со ссылкой песни в качестве адреса.
+// @Tags export
+// @Produce json
+// @Produce plain
+// @Param format query string false "Формат: json (по умолчанию), ndjson, csv, m3u8 или xspf"
+// @Param group query string false "Название группы"
+// @Param song query string false "Название песни"
+// @Param release_date query string false "Дата выпуска"
+// @Param link query string false "Ссылка"
+// @Param enrichment_status query string false "Статус получения информации: pending, enriched, failed"
+// @Success 200 {array} models.Song "Песни с куплетами"
+// @Failure 400 {string} string "Неизвестный формат"
+// @Failure 500 {string} string "Ошибка выгрузки песен"
+// @Router /export [get]
+func (h *Handler) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exporter.FormatJSON
+	}
+	out, err := exporter.NewWriter(w, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("songs-%s.%s", time.Now().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", exporter.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// После начала передачи статус ответа изменить нельзя: при ошибке выгрузка обрывается,
+	// и клиент получает неполный файл
+	written := 0
+	err = h.musicService.ExportSongs(r.Context(), songFilter(r), func(song models.Song) error {
+		written++
+		return out.Write(song)
+	})
+	if err != nil && written == 0 {
+		http.Error(w, fmt.Sprintf("ошибка выгрузки песен: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		log.Printf("Выгрузка песен прервана после %d песен: %v", written, err)
+		return
+	}
+	if err := out.Close(); err != nil {
+		log.Printf("Ошибка завершения выгрузки песен: %v", err)
+	}
+}
@@ -3,14 +3,18 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"music_library/internal/actor"
 	"music_library/internal/models"
 	"music_library/internal/service"
+	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
 
@@ -24,14 +28,22 @@ const (
 	offsetKey
 )
 
-// Handler содержит сервис для работы с музыкой
+// DuplicateResponse - тело ответа 409, если создаваемая песня уже есть в библиотеке
+type DuplicateResponse struct {
+	Error      string       `json:"error"`
+	Existing   *models.Song `json:"existing,omitempty"`
+	Similarity float64      `json:"similarity,omitempty"`
+}
+
+// Handler содержит сервисы для работы с музыкой и фоновыми задачами
 type Handler struct {
 	musicService service.MusicService
+	jobService   service.JobService
 }
 
 // NewHandler создает новый обработчик
-func NewHandler(musicService service.MusicService) *Handler {
-	return &Handler{musicService: musicService}
+func NewHandler(musicService service.MusicService, jobService service.JobService) *Handler {
+	return &Handler{musicService: musicService, jobService: jobService}
 }
 
 // CreateSong обрабатывает POST-запрос на создание новой песни
@@ -41,8 +53,12 @@ func NewHandler(musicService service.MusicService) *Handler {
 // @Accept json
 // @Produce json
 // @Param song body models.Song true "Данные песни"
+// @Param force query bool false "Сохранить песню, даже если найден дубликат"
+// @Param async query bool false "Создать песню в фоне и вернуть задачу"
 // @Success 201 {object} map[string]int "ID созданной песни"
+// @Success 202 {object} map[string]int "ID задачи, адрес задачи в заголовке Location"
 // @Failure 400 {string} string "Неверный формат JSON"
+// @Failure 409 {object} DuplicateResponse "Такая песня уже существует"
 // @Failure 500 {string} string "Ошибка создания песни"
 // @Router /songs [post]
 func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +69,26 @@ func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.musicService.AddSong(r.Context(), song)
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		jobID, err := h.jobService.EnqueueSong(r.Context(), song, force)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ошибка создания задачи: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/jobs/%d", jobID))
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, map[string]int{"job_id": jobID})
+		return
+	}
+
+	id, err := h.musicService.AddSong(r.Context(), song, force)
+	if errors.Is(err, models.ErrDuplicate) {
+		renderDuplicate(w, r, err)
+		return
+	}
 	if err != nil {
 		log.Printf("Ошибка создания песни: %v", err)
 		http.Error(w, fmt.Sprintf("ошибка создания песни: %v", err), http.StatusInternalServerError)
@@ -75,31 +110,25 @@ func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 // @Param song query string false "Название песни"
 // @Param release_date query string false "Дата выпуска"
 // @Param link query string false "Ссылка"
+// @Param enrichment_status query string false "Статус получения информации: pending, enriched, failed"
+// @Param sort query string false "Сортировка: popularity (по прослушиваниям) или rating (по средней оценке), песни возвращаются со статистикой"
 // @Success 200 {array} models.Song "Список песен"
+// @Failure 400 {string} string "Неизвестная сортировка"
 // @Failure 500 {string} string "Ошибка получения песен"
 // @Router /songs [get]
 func (h *Handler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	limit, ok := ctx.Value(limitKey).(int)
-	if !ok {
-		limit = 10 // Значение по умолчанию, если limit не найден
-	}
+	limit, offset := paginationFromContext(r)
 
-	offset, ok := ctx.Value(offsetKey).(int)
-	if !ok {
-		offset = 0 // Значение по умолчанию, если offset не найден
-	}
+	filter := songFilter(r)
+	sort := r.URL.Query().Get("sort")
 
-	// Получение фильтров из параметров запроса
-	filter := models.Song{
-		Group:       r.URL.Query().Get("group"),
-		Song:        r.URL.Query().Get("song"),
-		ReleaseDate: r.URL.Query().Get("release_date"),
-		Link:        r.URL.Query().Get("link"),
+	log.Printf("Получение списка песен с limit=%d, offset=%d, filter=%+v, sort=%q", limit, offset, filter, sort)
+	songs, err := h.musicService.GetSongs(ctx, limit, offset, filter, sort)
+	if errors.Is(err, models.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	log.Printf("Получение списка песен с limit=%d, offset=%d, filter=%+v", limit, offset, filter)
-	songs, err := h.musicService.GetSongs(ctx, limit, offset, filter)
 	if err != nil {
 		log.Printf("Ошибка получения песен: %v", err)
 		http.Error(w, "Ошибка получения песен", http.StatusInternalServerError)
@@ -111,11 +140,13 @@ func (h *Handler) GetSongs(w http.ResponseWriter, r *http.Request) {
 
 // GetSong обрабатывает GET-запрос на получение песни по ID.
 // @Summary Получить песню по ID
-// @Description Возвращает песню по ее ID.
+// @Description Возвращает песню по ее ID вместе со статистикой: средней оценкой, количеством оценок, прослушиваний и добавлений в избранное.
 // @Tags songs
 // @Param id path int true "ID песни"
 // @Success 200 {object} models.Song "Данные песни"
+// @Success 301 {string} string "Песня присоединена к другой песне"
 // @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
 // @Failure 500 {string} string "Ошибка получения песни"
 // @Router /songs/{id} [get]
 func (h *Handler) GetSong(w http.ResponseWriter, r *http.Request) {
@@ -127,6 +158,15 @@ func (h *Handler) GetSong(w http.ResponseWriter, r *http.Request) {
 	}
 
 	song, err := h.musicService.GetSongByID(r.Context(), id)
+	var movedErr *models.SongMovedError
+	if errors.As(err, &movedErr) {
+		http.Redirect(w, r, fmt.Sprintf("/songs/%d", movedErr.TargetID), http.StatusMovedPermanently)
+		return
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("ошибка получения песни: %v", err), http.StatusInternalServerError)
 		return
@@ -143,6 +183,7 @@ func (h *Handler) GetSong(w http.ResponseWriter, r *http.Request) {
 // @Param song body models.Song true "Новые данные песни"
 // @Success 200 {object} map[string]string "Статус обновления"
 // @Failure 400 {string} string "Неверный ID или формат JSON"
+// @Failure 409 {object} DuplicateResponse "Песня с такими названиями уже существует"
 // @Failure 500 {string} string "Ошибка обновления песни"
 // @Router /songs/{id} [put]
 func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
@@ -161,7 +202,12 @@ func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 
 	song.ID = id
 
-	if err := h.musicService.UpdateSong(r.Context(), song); err != nil {
+	err = h.musicService.UpdateSong(r.Context(), song)
+	if errors.Is(err, models.ErrDuplicate) {
+		renderDuplicate(w, r, err)
+		return
+	}
+	if err != nil {
 		http.Error(w, fmt.Sprintf("ошибка обновления песни: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -233,6 +279,56 @@ func (h *Handler) AddVerses(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// UpdateVerse изменяет текст куплета.
+// @Summary Изменить куплет
+// @Description Изменяет текст куплета песни. Аннотации к тексту переносятся на найденные фрагменты,
+// @Description аннотации, фрагменты которых не найдены, помечаются осиротевшими.
+// @Tags verses
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param number path int true "Номер куплета"
+// @Param verse body models.VerseRequest true "Новый текст куплета"
+// @Success 200 {object} models.Verse "Измененный куплет"
+// @Failure 400 {string} string "Неверный ID, номер куплета, формат JSON или пустой текст"
+// @Failure 404 {string} string "Куплет не найден"
+// @Failure 500 {string} string "Ошибка изменения куплета"
+// @Router /songs/{id}/verses/{number} [put]
+func (h *Handler) UpdateVerse(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil {
+		http.Error(w, "неверный номер куплета", http.StatusBadRequest)
+		return
+	}
+
+	var req models.VerseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	verse, err := h.musicService.UpdateVerse(r.Context(), songID, number, req.Text)
+	if errors.Is(err, models.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка изменения куплета: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, verse)
+}
+
 // GetVerses получает куплеты песни с пагинацией.
 // @Summary Получить куплеты
 // @Description Возвращает куплеты песни с пагинацией.
@@ -268,6 +364,31 @@ func (h *Handler) GetVerses(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(verses)
 }
 
+// songFilter возвращает фильтр списка песен из параметров запроса
+func songFilter(r *http.Request) models.Song {
+	return models.Song{
+		Group:       r.URL.Query().Get("group"),
+		Song:        r.URL.Query().Get("song"),
+		ReleaseDate: r.URL.Query().Get("release_date"),
+		Link:        r.URL.Query().Get("link"),
+
+		EnrichmentStatus: r.URL.Query().Get("enrichment_status"),
+	}
+}
+
+// renderDuplicate отвечает статусом 409 и, если известна, существующей песней-дубликатом
+func renderDuplicate(w http.ResponseWriter, r *http.Request, err error) {
+	resp := DuplicateResponse{Error: err.Error()}
+	var dupErr *models.DuplicateSongError
+	if errors.As(err, &dupErr) {
+		resp.Existing = &dupErr.Existing
+		resp.Similarity = dupErr.Similarity
+	}
+
+	render.Status(r, http.StatusConflict)
+	render.JSON(w, r, resp)
+}
+
 // Paginate middleware для пагинации.
 func Paginate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -284,6 +405,35 @@ func Paginate(next http.Handler) http.Handler {
 	})
 }
 
+// paginationFromContext возвращает параметры пагинации, сохраненные middleware Paginate
+func paginationFromContext(r *http.Request) (int, int) {
+	limit, ok := r.Context().Value(limitKey).(int)
+	if !ok {
+		limit = 10 // Значение по умолчанию, если limit не найден
+	}
+
+	offset, ok := r.Context().Value(offsetKey).(int)
+	if !ok {
+		offset = 0 // Значение по умолчанию, если offset не найден
+	}
+	return limit, offset
+}
+
+// Actor middleware сохраняет в контексте идентификатор запроса и адрес клиента. Они попадают
+// в историю изменений песен и журнал аудита вместе с инициатором, которого задает Authenticate.
+// Инициатором записывается только проверенное имя пользователя или ключа API, анонимные
+// изменения записываются от имени actor.Anonymous. Применяется после middleware.RequestID и middleware.RealIP.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ctx := actor.WithRequest(r.Context(), actor.Request{ID: middleware.GetReqID(r.Context()), IP: ip})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // parseLimitOffset извлекает параметры пагинации limit и offset из запроса
 func parseLimitOffset(r *http.Request) (int, int, error) {
 	limitStr := r.URL.Query().Get("limit")
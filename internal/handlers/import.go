@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+// maxImportFileSize - максимальный размер загружаемого файла импорта песен
+const maxImportFileSize = 100 << 20
+
+// ImportSongs обрабатывает POST-запрос на импорт песен из файла.
+// @Summary Импортировать песни
+// @Description Импортирует песни из файла CSV, JSON (массив) или NDJSON с полями group, song, date, link, lyrics.
+// @Description Файл читается построчно, песни ищутся по названиям группы и песни: новые добавляются, у существующих обновляются
+// @Description заполненные поля, поэтому повторный импорт ничего не меняет. В режиме insert существующие песни не изменяются
+// @Description и учитываются как пропущенные. Ошибки отдельных строк возвращаются в итоге. Размер файла - не больше 100 МБ.
+// @Tags import
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Файл импорта"
+// @Param format query string false "Формат: csv, json или ndjson. По умолчанию определяется по расширению файла"
+// @Param mode query string false "Режим: upsert (по умолчанию) - добавлять и обновлять, insert - только добавлять новые песни"
+// @Param dry_run query bool false "Проверить файл без сохранения"
+// @Success 200 {object} models.ImportReport "Итог импорта"
+// @Failure 400 {string} string "Нет файла, неизвестный формат или режим"
+// @Failure 413 {string} string "Файл слишком большой"
+// @Failure 500 {string} string "Ошибка импорта"
+// @Router /import [post]
+func (h *Handler) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	var opts models.ImportOptions
+	if err := opts.SetMode(r.URL.Query().Get("mode")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "ожидается multipart/form-data с полем file", http.StatusBadRequest)
+		return
+	}
+
+	// Файл читается из тела запроса по частям, без сохранения на диск
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "в запросе нет поля file", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ошибка чтения запроса: %v", err), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = importer.FormatFromName(part.FileName())
+		}
+		rows, err := importer.NewReader(part, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report, err := h.musicService.ImportSongs(r.Context(), rows, opts)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("файл импорта больше %d байт", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ошибка импорта: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		render.JSON(w, r, report)
+		return
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// GetJob обрабатывает GET-запрос на получение фоновой задачи.
+// @Summary Получить задачу
+// @Description Возвращает статус фоновой задачи (queued, running, succeeded, failed), ее результат или ошибку.
+// @Tags jobs
+// @Produce json
+// @Param id path int true "ID задачи"
+// @Success 200 {object} models.Job "Задача"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Задача не найдена"
+// @Failure 500 {string} string "Ошибка получения задачи"
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.jobService.GetJob(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения задачи: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, job)
+}
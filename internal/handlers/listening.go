@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music_library/internal/auth"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// ListeningHandler обрабатывает запросы к избранному, оценкам и истории прослушиваний
+// вошедшего пользователя
+type ListeningHandler struct {
+	listening service.ListeningService
+}
+
+// NewListeningHandler создает новый обработчик избранного, оценок и истории прослушиваний
+func NewListeningHandler(listening service.ListeningService) *ListeningHandler {
+	return &ListeningHandler{listening: listening}
+}
+
+// GetFavorites обрабатывает GET-запрос на получение избранного.
+// @Summary Получить избранное
+// @Description Возвращает избранные песни вошедшего пользователя, недавно добавленные первыми.
+// @Tags me
+// @Produce json
+// @Param limit query int false "Количество песен на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {array} models.Favorite "Избранные песни"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 500 {string} string "Ошибка получения избранного"
+// @Router /me/favorites [get]
+func (h *ListeningHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := paginationFromContext(r)
+
+	favorites, err := h.listening.GetFavorites(r.Context(), user.ID, limit, offset)
+	if err != nil {
+		renderListeningError(w, err, "ошибка получения избранного")
+		return
+	}
+
+	render.JSON(w, r, favorites)
+}
+
+// AddFavorite обрабатывает POST-запрос на добавление песни в избранное.
+// @Summary Добавить в избранное
+// @Description Добавляет песню в избранное вошедшего пользователя. Повторное добавление не считается ошибкой.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param favorite body models.FavoriteRequest true "ID песни"
+// @Success 200 {object} map[string]string "Статус добавления"
+// @Failure 400 {string} string "Неверный формат JSON или ID песни"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка добавления в избранное"
+// @Router /me/favorites [post]
+func (h *ListeningHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.FavoriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.listening.AddFavorite(r.Context(), user.ID, req.SongID); err != nil {
+		renderListeningError(w, err, "ошибка добавления в избранное")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// RemoveFavorite обрабатывает DELETE-запрос на удаление песни из избранного.
+// @Summary Удалить из избранного
+// @Description Удаляет песню из избранного вошедшего пользователя.
+// @Tags me
+// @Param id path int true "ID песни"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песни нет в избранном"
+// @Failure 500 {string} string "Ошибка удаления из избранного"
+// @Router /me/favorites/{id} [delete]
+func (h *ListeningHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.listening.RemoveFavorite(r.Context(), user.ID, songID); err != nil {
+		renderListeningError(w, err, "ошибка удаления из избранного")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// GetRating обрабатывает GET-запрос на получение оценки песни.
+// @Summary Получить свою оценку песни
+// @Description Возвращает оценку песни вошедшим пользователем. Средняя оценка всех пользователей возвращается в GET /songs/{id}.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {object} models.SongRating "Оценка"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песня не оценена"
+// @Failure 500 {string} string "Ошибка получения оценки"
+// @Router /songs/{id}/rating [get]
+func (h *ListeningHandler) GetRating(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := h.listening.GetRating(r.Context(), user.ID, songID)
+	if err != nil {
+		renderListeningError(w, err, "ошибка получения оценки")
+		return
+	}
+
+	render.JSON(w, r, rating)
+}
+
+// RateSong обрабатывает PUT-запрос на оценку песни.
+// @Summary Оценить песню
+// @Description Сохраняет оценку песни вошедшим пользователем от 1 до 5, прежняя оценка заменяется.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param rating body models.RatingRequest true "Оценка от 1 до 5"
+// @Success 200 {object} models.SongRating "Оценка"
+// @Failure 400 {string} string "Неверный ID, формат JSON или оценка"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сохранения оценки"
+// @Router /songs/{id}/rating [put]
+func (h *ListeningHandler) RateSong(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := h.listening.RateSong(r.Context(), user.ID, songID, req.Rating)
+	if err != nil {
+		renderListeningError(w, err, "ошибка сохранения оценки")
+		return
+	}
+
+	render.JSON(w, r, rating)
+}
+
+// DeleteRating обрабатывает DELETE-запрос на удаление оценки песни.
+// @Summary Удалить оценку песни
+// @Description Удаляет оценку песни вошедшим пользователем.
+// @Tags songs
+// @Param id path int true "ID песни"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песня не оценена"
+// @Failure 500 {string} string "Ошибка удаления оценки"
+// @Router /songs/{id}/rating [delete]
+func (h *ListeningHandler) DeleteRating(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.listening.DeleteRating(r.Context(), user.ID, songID); err != nil {
+		renderListeningError(w, err, "ошибка удаления оценки")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// GetHistory обрабатывает GET-запрос на получение истории прослушиваний.
+// @Summary Получить историю прослушиваний
+// @Description Возвращает прослушивания вошедшего пользователя, последние первыми.
+// @Tags me
+// @Produce json
+// @Param limit query int false "Количество прослушиваний на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {array} models.Play "История прослушиваний"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 500 {string} string "Ошибка получения истории прослушиваний"
+// @Router /me/history [get]
+func (h *ListeningHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := paginationFromContext(r)
+
+	plays, err := h.listening.GetHistory(r.Context(), user.ID, limit, offset)
+	if err != nil {
+		renderListeningError(w, err, "ошибка получения истории прослушиваний")
+		return
+	}
+
+	render.JSON(w, r, plays)
+}
+
+// RecordPlay обрабатывает POST-запрос на запись прослушивания.
+// @Summary Записать прослушивание
+// @Description Добавляет прослушивание песни в историю вошедшего пользователя. Без played_at используется текущее время.
+// @Description Количество прослушиваний определяет популярность песни (GET /songs?sort=popularity).
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param play body models.PlayRequest true "ID песни и время прослушивания"
+// @Success 201 {object} map[string]int "ID записи прослушивания"
+// @Failure 400 {string} string "Неверный формат JSON, ID песни или время прослушивания"
+// @Failure 401 {string} string "Требуется вход пользователя"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка записи прослушивания"
+// @Router /me/history [post]
+func (h *ListeningHandler) RecordPlay(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PlayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.listening.RecordPlay(r.Context(), user.ID, req)
+	if err != nil {
+		renderListeningError(w, err, "ошибка записи прослушивания")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, map[string]int64{"id": id})
+}
+
+// currentUser возвращает вошедшего пользователя или отвечает статусом 401. Избранное, оценки
+// и история принадлежат пользователю, поэтому запросы без входа и с ключом API отклоняются.
+func currentUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		unauthorized(w, "требуется вход пользователя")
+	}
+	return user, ok
+}
+
+// renderListeningError отправляет ошибку сервиса избранного, оценок и истории с подходящим статусом
+func renderListeningError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+	}
+}
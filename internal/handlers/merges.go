@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// MergeSong обрабатывает POST-запрос на слияние песни-дубликата с песней из пути.
+// @Summary Объединить песни
+// @Description Присоединяет песню source_id к песне из пути: куплеты и поля переносятся по правилам policy
+// @Description (keep, replace, fill_empty, для куплетов также append). Старый ID перенаправляет на сохраняемую песню.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сохраняемой песни"
+// @Param merge body models.MergeRequest true "ID присоединяемой песни и правила слияния"
+// @Success 200 {object} map[string]string "Статус слияния"
+// @Failure 400 {string} string "Неверный ID или правила слияния"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 409 {object} DuplicateResponse "Песня с итоговыми названиями уже существует"
+// @Failure 500 {string} string "Ошибка слияния песен"
+// @Router /songs/{id}/merge [post]
+func (h *Handler) MergeSong(w http.ResponseWriter, r *http.Request) {
+	survivorID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	err = h.musicService.MergeSongs(r.Context(), survivorID, req)
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrDuplicate):
+		renderDuplicate(w, r, err)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("ошибка слияния песен: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// GetSongMerges обрабатывает GET-запрос на получение журнала слияний песни.
+// @Summary Получить журнал слияний песни
+// @Description Возвращает песни, присоединенные к песне, с правилами слияния и их исходными данными.
+// @Tags songs
+// @Param id path int true "ID песни"
+// @Success 200 {array} models.SongMerge "Журнал слияний"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка получения журнала слияний"
+// @Router /songs/{id}/merges [get]
+func (h *Handler) GetSongMerges(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	merges, err := h.musicService.GetSongMerges(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения журнала слияний: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, merges)
+}
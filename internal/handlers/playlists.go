@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"music_library/internal/exporter"
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// PlaylistHandler обрабатывает запросы к плейлистам
+type PlaylistHandler struct {
+	playlists service.PlaylistService
+}
+
+// NewPlaylistHandler создает новый обработчик плейлистов
+func NewPlaylistHandler(playlists service.PlaylistService) *PlaylistHandler {
+	return &PlaylistHandler{playlists: playlists}
+}
+
+// CreatePlaylist обрабатывает POST-запрос на создание плейлиста.
+// @Summary Создать плейлист
+// @Description Создает пустой плейлист. Песни добавляются отдельными запросами.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param playlist body models.Playlist true "Название и описание плейлиста"
+// @Success 201 {object} map[string]int "ID созданного плейлиста"
+// @Failure 400 {string} string "Неверный формат JSON или пустое название"
+// @Failure 500 {string} string "Ошибка создания плейлиста"
+// @Router /playlists [post]
+func (h *PlaylistHandler) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	var playlist models.Playlist
+	if err := json.NewDecoder(r.Body).Decode(&playlist); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.playlists.CreatePlaylist(r.Context(), playlist)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка создания плейлиста")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, map[string]int{"id": id})
+}
+
+// GetPlaylists обрабатывает GET-запрос на получение списка плейлистов.
+// @Summary Получить список плейлистов
+// @Description Возвращает плейлисты без песен с пагинацией.
+// @Tags playlists
+// @Produce json
+// @Param limit query int false "Количество плейлистов на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {array} models.Playlist "Список плейлистов"
+// @Failure 500 {string} string "Ошибка получения плейлистов"
+// @Router /playlists [get]
+func (h *PlaylistHandler) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	limit, offset := paginationFromContext(r)
+
+	playlists, err := h.playlists.GetPlaylists(r.Context(), limit, offset)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения плейлистов: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, playlists)
+}
+
+// GetPlaylist обрабатывает GET-запрос на получение плейлиста с песнями.
+// @Summary Получить плейлист
+// @Description Возвращает плейлист и его элементы в порядке позиций вместе с данными песен (без куплетов).
+// @Tags playlists
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Success 200 {object} models.Playlist "Плейлист с песнями"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Failure 500 {string} string "Ошибка получения плейлиста"
+// @Router /playlists/{id} [get]
+func (h *PlaylistHandler) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := h.playlists.GetPlaylist(r.Context(), id)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка получения плейлиста")
+		return
+	}
+
+	render.JSON(w, r, playlist)
+}
+
+// UpdatePlaylist обрабатывает PUT-запрос на изменение плейлиста.
+// @Summary Изменить плейлист
+// @Description Изменяет название и описание плейлиста. Элементы плейлиста не изменяются.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param playlist body models.Playlist true "Новые название и описание"
+// @Success 200 {object} map[string]string "Статус обновления"
+// @Failure 400 {string} string "Неверный ID, формат JSON или пустое название"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Failure 500 {string} string "Ошибка обновления плейлиста"
+// @Router /playlists/{id} [put]
+func (h *PlaylistHandler) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var playlist models.Playlist
+	if err := json.NewDecoder(r.Body).Decode(&playlist); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	playlist.ID = id
+
+	if err := h.playlists.UpdatePlaylist(r.Context(), playlist); err != nil {
+		renderPlaylistError(w, err, "ошибка обновления плейлиста")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// DeletePlaylist обрабатывает DELETE-запрос на удаление плейлиста.
+// @Summary Удалить плейлист
+// @Description Удаляет плейлист вместе с элементами. Сами песни не удаляются.
+// @Tags playlists
+// @Param id path int true "ID плейлиста"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Failure 500 {string} string "Ошибка удаления плейлиста"
+// @Router /playlists/{id} [delete]
+func (h *PlaylistHandler) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.playlists.DeletePlaylist(r.Context(), id); err != nil {
+		renderPlaylistError(w, err, "ошибка удаления плейлиста")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// AddPlaylistItem обрабатывает POST-запрос на добавление песни в плейлист.
+// @Summary Добавить песню в плейлист
+// @Description Вставляет песню на позицию position (с единицы), последующие элементы сдвигаются.
+// @Description Без позиции песня добавляется в конец. Одна песня может входить в плейлист несколько раз.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param item body models.PlaylistItemRequest true "ID песни и позиция"
+// @Success 201 {object} map[string]int "ID элемента плейлиста"
+// @Failure 400 {string} string "Неверный ID, формат JSON или позиция"
+// @Failure 404 {string} string "Плейлист или песня не найдены"
+// @Failure 500 {string} string "Ошибка добавления песни в плейлист"
+// @Router /playlists/{id}/items [post]
+func (h *PlaylistHandler) AddPlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PlaylistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := h.playlists.AddPlaylistItem(r.Context(), id, req)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка добавления песни в плейлист")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, map[string]int{"id": itemID})
+}
+
+// RemovePlaylistItem обрабатывает DELETE-запрос на удаление элемента плейлиста.
+// @Summary Удалить песню из плейлиста
+// @Description Удаляет элемент плейлиста, последующие элементы сдвигаются на его место.
+// @Tags playlists
+// @Param id path int true "ID плейлиста"
+// @Param item path int true "ID элемента плейлиста"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Плейлист или элемент не найдены"
+// @Failure 500 {string} string "Ошибка удаления элемента плейлиста"
+// @Router /playlists/{id}/items/{item} [delete]
+func (h *PlaylistHandler) RemovePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID, err := playlistItemIDs(r)
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.playlists.RemovePlaylistItem(r.Context(), id, itemID); err != nil {
+		renderPlaylistError(w, err, "ошибка удаления элемента плейлиста")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// MovePlaylistItem обрабатывает POST-запрос на перемещение элемента плейлиста.
+// @Summary Переместить песню в плейлисте
+// @Description Перемещает элемент на позицию position (с единицы), элементы между старой и новой позицией сдвигаются.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param item path int true "ID элемента плейлиста"
+// @Param move body models.PlaylistItemRequest true "Новая позиция"
+// @Success 200 {object} map[string]string "Статус перемещения"
+// @Failure 400 {string} string "Неверный ID, формат JSON или позиция"
+// @Failure 404 {string} string "Плейлист или элемент не найдены"
+// @Failure 500 {string} string "Ошибка перемещения элемента плейлиста"
+// @Router /playlists/{id}/items/{item}/move [post]
+func (h *PlaylistHandler) MovePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID, err := playlistItemIDs(r)
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PlaylistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.playlists.MovePlaylistItem(r.Context(), id, itemID, req.Position); err != nil {
+		renderPlaylistError(w, err, "ошибка перемещения элемента плейлиста")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// ExportPlaylist обрабатывает GET-запрос на выгрузку плейлиста в формате проигрывателей.
+// @Summary Выгрузить плейлист
+// @Description Выгружает песни плейлиста по порядку в формате M3U8 или XSPF, адресом записи служит ссылка песни.
+// @Description В M3U8 песни без ссылки пропускаются, в XSPF записываются только исполнитель и название.
+// @Tags playlists
+// @Produce plain
+// @Param id path int true "ID плейлиста"
+// @Param format query string false "Формат: m3u8 (по умолчанию) или xspf"
+// @Success 200 {string} string "Файл плейлиста"
+// @Failure 400 {string} string "Неверный ID или неизвестный формат"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Failure 500 {string} string "Ошибка выгрузки плейлиста"
+// @Router /playlists/{id}/export [get]
+func (h *PlaylistHandler) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exporter.FormatM3U8
+	}
+	if format != exporter.FormatM3U8 && format != exporter.FormatXSPF {
+		http.Error(w, fmt.Sprintf("неизвестный формат плейлиста: %q, ожидается m3u8 или xspf", format), http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := h.playlists.GetPlaylist(r.Context(), id)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка выгрузки плейлиста")
+		return
+	}
+
+	out, err := exporter.NewTitledWriter(w, format, playlist.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, id, format))
+
+	for _, item := range playlist.Items {
+		if err := out.Write(item.Song); err != nil {
+			log.Printf("Выгрузка плейлиста %d прервана: %v", id, err)
+			return
+		}
+	}
+	if err := out.Close(); err != nil {
+		log.Printf("Ошибка завершения выгрузки плейлиста: %v", err)
+	}
+}
+
+// maxPlaylistFileSize - максимальный размер загружаемого файла плейлиста
+const maxPlaylistFileSize = 10 << 20
+
+// ImportPlaylist обрабатывает POST-запрос на импорт плейлиста из файла проигрывателя.
+// @Summary Импортировать плейлист
+// @Description Читает плейлист M3U, M3U8 или XSPF и сопоставляет записи с песнями библиотеки по исполнителю и названию
+// @Description (из #EXTINF, creator и title или из имени файла "Исполнитель - Название"). Из найденных песен создается плейлист,
+// @Description записи без подходящей песни возвращаются в списке unmatched. Название берется из параметра name, из файла или из имени файла.
+// @Tags playlists
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Файл плейлиста"
+// @Param format query string false "Формат: m3u, m3u8 или xspf. По умолчанию определяется по расширению файла"
+// @Param name query string false "Название создаваемого плейлиста"
+// @Param dry_run query bool false "Сопоставить записи без создания плейлиста"
+// @Success 200 {object} models.PlaylistImportReport "Итог импорта"
+// @Failure 400 {string} string "Нет файла, неизвестный формат или ошибка разбора"
+// @Failure 500 {string} string "Ошибка импорта плейлиста"
+// @Router /playlists/import [post]
+func (h *PlaylistHandler) ImportPlaylist(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPlaylistFileSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "ожидается multipart/form-data с полем file", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "в запросе нет поля file", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ошибка чтения запроса: %v", err), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = importer.PlaylistFormatFromName(part.FileName())
+		}
+		title, entries, err := importer.ReadPlaylist(part, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			name = title
+		}
+		if name == "" {
+			name = strings.TrimSuffix(part.FileName(), filepath.Ext(part.FileName()))
+		}
+
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		report, err := h.playlists.ImportPlaylist(r.Context(), name, entries, dryRun)
+		if err != nil {
+			renderPlaylistError(w, err, "ошибка импорта плейлиста")
+			return
+		}
+
+		render.JSON(w, r, report)
+		return
+	}
+}
+
+// playlistItemIDs разбирает ID плейлиста и ID элемента из пути
+func playlistItemIDs(r *http.Request) (int, int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	itemID, err := strconv.Atoi(chi.URLParam(r, "item"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, itemID, nil
+}
+
+// renderPlaylistError отправляет ошибку сервиса плейлистов с подходящим статусом
+func renderPlaylistError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"music_library/internal/models"
+	"net/http"
+)
+
+// Problem - тело ответа об ошибке в формате RFC 9457 (application/problem+json)
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Permission - недостающее право доступа (расширение для ответа 403)
+	Permission models.Permission `json:"permission,omitempty"`
+}
+
+// renderProblem отправляет описание ошибки с типом содержимого application/problem+json
+func renderProblem(w http.ResponseWriter, problem Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("Ошибка отправки описания ошибки: %v", err)
+	}
+}
+
+// forbidden отвечает статусом 403 с указанием недостающего права
+func forbidden(w http.ResponseWriter, r *http.Request, perm models.Permission, detail string) {
+	renderProblem(w, Problem{
+		Title:      "Недостаточно прав",
+		Status:     http.StatusForbidden,
+		Detail:     detail,
+		Instance:   r.URL.Path,
+		Permission: perm,
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// RefreshSong обрабатывает POST-запрос на повторное получение информации о песне.
+// @Summary Обновить информацию о песне
+// @Description Повторно запрашивает информацию о песне у источника (минуя кэш) и обновляет дату релиза, ссылку и куплеты.
+// @Description Поля, заданные пользователем вручную, не изменяются и перечисляются в overridden. С dry_run=true изменения только возвращаются.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param dry_run query bool false "Показать изменения без сохранения"
+// @Success 200 {object} models.RefreshResult "Изменения песни"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена или неизвестна источнику"
+// @Failure 502 {string} string "Источник информации недоступен"
+// @Router /songs/{id}/refresh [post]
+func (h *Handler) RefreshSong(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := h.musicService.RefreshSong(r.Context(), id, dryRun)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка обновления информации о песне: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// RefreshSongs обрабатывает POST-запрос на повторное получение информации о списке песен.
+// @Summary Обновить информацию о песнях
+// @Description Повторно запрашивает информацию для песен, выбранных теми же фильтрами и пагинацией, что и GET /songs.
+// @Description Ошибка отдельной песни возвращается в поле error ее результата. С dry_run=true изменения только возвращаются.
+// @Tags songs
+// @Produce json
+// @Param limit query int false "Количество песен"
+// @Param offset query int false "Смещение от начала списка"
+// @Param group query string false "Название группы"
+// @Param song query string false "Название песни"
+// @Param release_date query string false "Дата выпуска"
+// @Param link query string false "Ссылка"
+// @Param enrichment_status query string false "Статус получения информации: pending, enriched, failed"
+// @Param dry_run query bool false "Показать изменения без сохранения"
+// @Success 200 {array} models.RefreshResult "Изменения песен"
+// @Failure 400 {string} string "Неверные параметры пагинации"
+// @Failure 500 {string} string "Ошибка получения песен"
+// @Router /songs/refresh [post]
+func (h *Handler) RefreshSongs(w http.ResponseWriter, r *http.Request) {
+	limit, offset := paginationFromContext(r)
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	results, err := h.musicService.RefreshSongs(r.Context(), limit, offset, songFilter(r), dryRun)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка обновления информации о песнях: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, results)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// GetRevisions обрабатывает GET-запрос на получение истории изменений песни.
+// @Summary Получить историю изменений песни
+// @Description Возвращает ревизии песни и ее куплетов, начиная с самой новой.
+// @Tags revisions
+// @Param id path int true "ID песни"
+// @Param limit query int false "Количество ревизий на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {array} models.SongRevision "Список ревизий"
+// @Failure 400 {string} string "Неверный ID песни или параметры пагинации"
+// @Failure 500 {string} string "Ошибка получения ревизий"
+// @Router /songs/{id}/revisions [get]
+func (h *Handler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID песни", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := paginationFromContext(r)
+	revisions, err := h.musicService.GetRevisions(r.Context(), songID, limit, offset)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения ревизий: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, revisions)
+}
+
+// GetRevision обрабатывает GET-запрос на получение ревизии песни.
+// @Summary Получить ревизию песни
+// @Description Возвращает снимок песни и отличия от предыдущей ревизии.
+// @Tags revisions
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} models.SongRevision "Ревизия"
+// @Failure 400 {string} string "Неверный ID песни или номер ревизии"
+// @Failure 404 {string} string "Ревизия не найдена"
+// @Failure 500 {string} string "Ошибка получения ревизии"
+// @Router /songs/{id}/revisions/{rev} [get]
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	songID, revision, err := parseRevisionParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rev, err := h.musicService.GetRevision(r.Context(), songID, revision)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения ревизии: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, rev)
+}
+
+// RevertToRevision обрабатывает POST-запрос на откат песни к ревизии.
+// @Summary Откатить песню к ревизии
+// @Description Восстанавливает данные песни и ее куплеты из ревизии. Откат сохраняется как новая ревизия.
+// @Tags revisions
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} map[string]string "Статус отката"
+// @Failure 400 {string} string "Неверный ID песни или номер ревизии"
+// @Failure 404 {string} string "Песня или ревизия не найдена"
+// @Failure 409 {object} DuplicateResponse "Песня с названиями из ревизии уже существует"
+// @Failure 500 {string} string "Ошибка отката песни"
+// @Router /songs/{id}/revisions/{rev}/revert [post]
+func (h *Handler) RevertToRevision(w http.ResponseWriter, r *http.Request) {
+	songID, revision, err := parseRevisionParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.musicService.RevertToRevision(r.Context(), songID, revision)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, models.ErrDuplicate) {
+		renderDuplicate(w, r, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка отката песни: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// DiffRevisions обрабатывает GET-запрос на построчное сравнение текста песни между ревизиями.
+// @Summary Сравнить ревизии песни
+// @Description Возвращает построчные отличия куплетов между ревизиями и их представление в формате unified diff.
+// @Tags revisions
+// @Param id path int true "ID песни"
+// @Param from query int true "Номер исходной ревизии"
+// @Param to query int false "Номер конечной ревизии, по умолчанию последняя"
+// @Success 200 {object} models.LyricsDiff "Отличия текста"
+// @Failure 400 {string} string "Неверный ID песни или номера ревизий"
+// @Failure 404 {string} string "Ревизия не найдена"
+// @Failure 500 {string} string "Ошибка сравнения ревизий"
+// @Router /songs/{id}/diff [get]
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID песни", http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from <= 0 {
+		http.Error(w, "неверный параметр from", http.StatusBadRequest)
+		return
+	}
+
+	to := 0
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to <= 0 {
+			http.Error(w, "неверный параметр to", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := h.musicService.DiffRevisions(r.Context(), songID, from, to)
+	if errors.Is(err, models.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка сравнения ревизий: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, diff)
+}
+
+// parseRevisionParams извлекает ID песни и номер ревизии из пути запроса
+func parseRevisionParams(r *http.Request) (int, int, error) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("неверный ID песни")
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil || revision <= 0 {
+		return 0, 0, fmt.Errorf("неверный номер ревизии")
+	}
+	return songID, revision, nil
+}
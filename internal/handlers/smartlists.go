@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// decodeSmartList читает умный список из тела запроса. Неизвестные поля правила считаются ошибкой,
+// чтобы опечатка в условии не превращала список во все песни библиотеки.
+func decodeSmartList(r *http.Request) (models.SmartList, error) {
+	var list models.SmartList
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&list); err != nil {
+		return models.SmartList{}, err
+	}
+	return list, nil
+}
+
+// CreateSmartList обрабатывает POST-запрос на создание умного списка.
+// @Summary Создать умный список
+// @Description Сохраняет правило отбора песен. Правило использует фильтры GET /songs (group, song, release_date, link, enrichment_status),
+// @Description границы даты выпуска released_after и released_before (YYYY или DD.MM.YYYY), сортировку sort (id, group, song, release_date),
+// @Description направление desc и наибольшее количество песен limit. Правило проверяется при сохранении.
+// @Tags smart-lists
+// @Accept json
+// @Produce json
+// @Param list body models.SmartList true "Название, описание и правило"
+// @Success 201 {object} map[string]int "ID созданного умного списка"
+// @Failure 400 {string} string "Неверный формат JSON или правило"
+// @Failure 500 {string} string "Ошибка создания умного списка"
+// @Router /smart-lists [post]
+func (h *PlaylistHandler) CreateSmartList(w http.ResponseWriter, r *http.Request) {
+	list, err := decodeSmartList(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("неверный формат JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.playlists.CreateSmartList(r.Context(), list)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка создания умного списка")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, map[string]int{"id": id})
+}
+
+// GetSmartLists обрабатывает GET-запрос на получение списка умных списков.
+// @Summary Получить умные списки
+// @Description Возвращает умные списки с правилами, без песен, с пагинацией.
+// @Tags smart-lists
+// @Produce json
+// @Param limit query int false "Количество списков на странице"
+// @Param offset query int false "Смещение от начала"
+// @Success 200 {array} models.SmartList "Умные списки"
+// @Failure 500 {string} string "Ошибка получения умных списков"
+// @Router /smart-lists [get]
+func (h *PlaylistHandler) GetSmartLists(w http.ResponseWriter, r *http.Request) {
+	limit, offset := paginationFromContext(r)
+
+	lists, err := h.playlists.GetSmartLists(r.Context(), limit, offset)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("ошибка получения умных списков: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, lists)
+}
+
+// GetSmartList обрабатывает GET-запрос на получение умного списка с песнями.
+// @Summary Получить умный список
+// @Description Возвращает умный список и песни, отобранные по его правилу в момент запроса.
+// @Description limit и offset задают страницу внутри списка.
+// @Tags smart-lists
+// @Produce json
+// @Param id path int true "ID умного списка"
+// @Param limit query int false "Количество песен на странице"
+// @Param offset query int false "Смещение от начала списка"
+// @Success 200 {object} models.SmartList "Умный список с песнями"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Умный список не найден"
+// @Failure 500 {string} string "Ошибка получения умного списка"
+// @Router /smart-lists/{id} [get]
+func (h *PlaylistHandler) GetSmartList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+	limit, offset := paginationFromContext(r)
+
+	list, err := h.playlists.GetSmartList(r.Context(), id, limit, offset)
+	if err != nil {
+		renderPlaylistError(w, err, "ошибка получения умного списка")
+		return
+	}
+
+	render.JSON(w, r, list)
+}
+
+// UpdateSmartList обрабатывает PUT-запрос на изменение умного списка.
+// @Summary Изменить умный список
+// @Description Заменяет название, описание и правило умного списка. Правило проверяется при сохранении.
+// @Tags smart-lists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID умного списка"
+// @Param list body models.SmartList true "Название, описание и правило"
+// @Success 200 {object} map[string]string "Статус обновления"
+// @Failure 400 {string} string "Неверный ID, формат JSON или правило"
+// @Failure 404 {string} string "Умный список не найден"
+// @Failure 500 {string} string "Ошибка обновления умного списка"
+// @Router /smart-lists/{id} [put]
+func (h *PlaylistHandler) UpdateSmartList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := decodeSmartList(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("неверный формат JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	list.ID = id
+
+	if err := h.playlists.UpdateSmartList(r.Context(), list); err != nil {
+		renderPlaylistError(w, err, "ошибка обновления умного списка")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// DeleteSmartList обрабатывает DELETE-запрос на удаление умного списка.
+// @Summary Удалить умный список
+// @Description Удаляет умный список. Песни не удаляются.
+// @Tags smart-lists
+// @Param id path int true "ID умного списка"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Умный список не найден"
+// @Failure 500 {string} string "Ошибка удаления умного списка"
+// @Router /smart-lists/{id} [delete]
+func (h *PlaylistHandler) DeleteSmartList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.playlists.DeleteSmartList(r.Context(), id); err != nil {
+		renderPlaylistError(w, err, "ошибка удаления умного списка")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
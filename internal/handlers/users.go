@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music_library/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// GetUsers обрабатывает GET-запрос на получение пользователей.
+// @Summary Получить пользователей
+// @Description Возвращает всех пользователей с их ролями.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.User "Пользователи"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка получения пользователей"
+// @Router /admin/users [get]
+func (h *AuthHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.auth.GetUsers(r.Context())
+	if err != nil {
+		renderAuthError(w, err, "ошибка получения пользователей")
+		return
+	}
+
+	render.JSON(w, r, users)
+}
+
+// CreateUser обрабатывает POST-запрос на регистрацию пользователя.
+// @Summary Зарегистрировать пользователя
+// @Description Регистрирует пользователя с ролью viewer (только чтение), editor (создание и изменение песен,
+// @Description куплетов и плейлистов) или admin (также удаление, слияние и управление пользователями).
+// @Description Без роли пользователь получает роль viewer.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user body models.UserRequest true "Имя, пароль и роль"
+// @Success 201 {object} map[string]int "ID пользователя"
+// @Failure 400 {string} string "Неверный формат JSON, пароль или роль"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 409 {string} string "Пользователь уже существует"
+// @Failure 500 {string} string "Ошибка регистрации пользователя"
+// @Router /admin/users [post]
+func (h *AuthHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.auth.CreateUser(r.Context(), req)
+	if err != nil {
+		renderAuthError(w, err, "ошибка регистрации пользователя")
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, map[string]int{"id": id})
+}
+
+// UpdateUserRole обрабатывает PUT-запрос на изменение роли пользователя.
+// @Summary Изменить роль пользователя
+// @Description Изменяет роль пользователя, новая роль действует со следующего запроса.
+// @Description Единственного администратора понизить нельзя.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param user body models.UserRequest true "Новая роль, имя и пароль не используются"
+// @Success 200 {object} map[string]string "Статус изменения"
+// @Failure 400 {string} string "Неверный ID, формат JSON или роль, либо пользователь - единственный администратор"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 500 {string} string "Ошибка изменения роли"
+// @Router /admin/users/{id} [put]
+func (h *AuthHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.auth.SetUserRole(r.Context(), id, req.Role); err != nil {
+		renderAuthError(w, err, "ошибка изменения роли")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
+
+// DeleteUser обрабатывает DELETE-запрос на удаление пользователя.
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя и его токены обновления, выданные ему токены доступа сразу перестают действовать.
+// @Description Единственного администратора удалить нельзя.
+// @Tags admin
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "Статус удаления"
+// @Failure 400 {string} string "Неверный ID или пользователь - единственный администратор"
+// @Failure 401 {string} string "Требуется вход"
+// @Failure 403 {object} Problem "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 500 {string} string "Ошибка удаления пользователя"
+// @Router /admin/users/{id} [delete]
+func (h *AuthHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.auth.DeleteUser(r.Context(), id); err != nil {
+		renderAuthError(w, err, "ошибка удаления пользователя")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"status": "ok"})
+}
@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"music_library/internal/models"
+	"music_library/internal/tags"
+	"path/filepath"
+	"time"
+)
+
+// audioReader читает строки импорта из метаданных аудиофайлов каталога
+type audioReader struct {
+	paths []string
+	next  int
+}
+
+// NewAudioReader возвращает строки импорта из тегов MP3- и FLAC-файлов каталога root
+// и его подкаталогов: исполнитель, название, дата и текст песни. Номер строки - порядковый
+// номер файла, путь к файлу записывается в Source.
+func NewAudioReader(root string) (Reader, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && tags.Supported(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обхода каталога: %w", err)
+	}
+	return &audioReader{paths: paths}, nil
+}
+
+// Read читает метаданные следующего файла
+func (r *audioReader) Read() (models.ImportRow, error) {
+	if r.next >= len(r.paths) {
+		return models.ImportRow{}, io.EOF
+	}
+	path := r.paths[r.next]
+	r.next++
+
+	t, err := tags.ReadFile(path)
+	if err != nil {
+		return models.ImportRow{}, &RowError{Line: r.next, Source: path, Err: err}
+	}
+	return models.ImportRow{
+		Line:        r.next,
+		Source:      path,
+		Group:       t.Artist,
+		Song:        t.Title,
+		ReleaseDate: formatTagDate(t.Date),
+		Lyrics:      t.Lyrics,
+	}, nil
+}
+
+// formatTagDate приводит дату из тега вида 2006-01-02 (в том числе со временем) к формату
+// библиотеки 02.01.2006. Год и даты в других форматах возвращаются без изменений.
+func formatTagDate(value string) string {
+	if len(value) < len("2006-01-02") {
+		return value
+	}
+	date, err := time.Parse("2006-01-02", value[:len("2006-01-02")])
+	if err != nil {
+		return value
+	}
+	return date.Format("02.01.2006")
+}
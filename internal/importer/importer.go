@@ -0,0 +1,235 @@
+// Package importer читает строки импорта песен из CSV, JSON и NDJSON по одной,
+// не загружая файл в память целиком.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"music_library/internal/models"
+	"path/filepath"
+	"strings"
+)
+
+// Форматы файлов импорта
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineSize - максимальный размер строки NDJSON
+const maxLineSize = 1 << 20
+
+// Reader читает строки импорта. В конце файла Read возвращает io.EOF.
+// Ошибка отдельной строки возвращается как *RowError, после нее чтение можно продолжить.
+type Reader interface {
+	Read() (models.ImportRow, error)
+}
+
+// RowError - ошибка разбора одной строки файла. Source - файл, к которому относится ошибка,
+// если строки читаются из нескольких файлов.
+type RowError struct {
+	Line   int
+	Source string
+	Err    error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("строка %d: %v", e.Line, e.Err) }
+func (e *RowError) Unwrap() error { return e.Err }
+
+// FormatFromName определяет формат по расширению файла, для неизвестного расширения возвращается пустая строка
+func FormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return ""
+	}
+}
+
+// NewReader создает Reader для формата format
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSON:
+		return newJSONReader(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат импорта: %q, ожидается csv, json или ndjson", format)
+	}
+}
+
+// jsonRow - строка JSON и NDJSON. Кроме основных полей принимаются
+// release_date вместо date и text вместо lyrics.
+type jsonRow struct {
+	models.ImportRow
+	AltReleaseDate string `json:"release_date"`
+	AltLyrics      string `json:"text"`
+}
+
+// toModel возвращает строку импорта с учетом альтернативных имен полей
+func (row jsonRow) toModel(line int) models.ImportRow {
+	result := row.ImportRow
+	result.Line = line
+	if result.ReleaseDate == "" {
+		result.ReleaseDate = row.AltReleaseDate
+	}
+	if result.Lyrics == "" {
+		result.Lyrics = row.AltLyrics
+	}
+	return result
+}
+
+// csvReader читает CSV-файл со строкой заголовка
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// csvColumns - допустимые имена столбцов CSV для каждого поля
+var csvColumns = map[string][]string{
+	"group":  {"group"},
+	"song":   {"song"},
+	"date":   {"date", "release_date", "releasedate"},
+	"link":   {"link"},
+	"lyrics": {"lyrics", "text"},
+}
+
+// newCSVReader читает строку заголовка и сопоставляет столбцы полям
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка CSV: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for field, aliases := range csvColumns {
+			for _, alias := range aliases {
+				if name == alias {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	for _, field := range []string{"group", "song"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("в заголовке CSV нет столбца %s", field)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+// Read читает следующую строку CSV
+func (r *csvReader) Read() (models.ImportRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return models.ImportRow{}, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+		}
+		return models.ImportRow{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return models.ImportRow{
+		Line:        line,
+		Group:       field("group"),
+		Song:        field("song"),
+		ReleaseDate: field("date"),
+		Link:        field("link"),
+		Lyrics:      field("lyrics"),
+	}, nil
+}
+
+// jsonReader читает JSON-массив строк по одному элементу
+type jsonReader struct {
+	decoder *json.Decoder
+	index   int
+}
+
+// newJSONReader проверяет начало массива
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("ожидается JSON-массив строк импорта")
+	}
+	return &jsonReader{decoder: decoder}, nil
+}
+
+// Read читает следующий элемент массива. Номер строки - порядковый номер элемента, начиная с единицы.
+func (r *jsonReader) Read() (models.ImportRow, error) {
+	if !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return models.ImportRow{}, fmt.Errorf("ошибка чтения JSON: %w", err)
+		}
+		return models.ImportRow{}, io.EOF
+	}
+
+	r.index++
+	var row jsonRow
+	if err := r.decoder.Decode(&row); err != nil {
+		// После синтаксической ошибки продолжить чтение массива нельзя
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return models.ImportRow{}, &RowError{Line: r.index, Err: err}
+		}
+		return models.ImportRow{}, fmt.Errorf("элемент %d: ошибка чтения JSON: %w", r.index, err)
+	}
+	return row.toModel(r.index), nil
+}
+
+// ndjsonReader читает по одному JSON-объекту на строку, пустые строки пропускаются
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Read читает следующую строку NDJSON
+func (r *ndjsonReader) Read() (models.ImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var row jsonRow
+		if err := json.Unmarshal(data, &row); err != nil {
+			return models.ImportRow{}, &RowError{Line: r.line, Err: err}
+		}
+		return row.toModel(r.line), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return models.ImportRow{}, fmt.Errorf("ошибка чтения NDJSON: %w", err)
+	}
+	return models.ImportRow{}, io.EOF
+}
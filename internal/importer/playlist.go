@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"music_library/internal/models"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Форматы файлов плейлистов
+const (
+	FormatM3U  = "m3u"
+	FormatM3U8 = "m3u8"
+	FormatXSPF = "xspf"
+)
+
+// PlaylistFormatFromName определяет формат плейлиста по расширению файла,
+// для неизвестного расширения возвращается пустая строка
+func PlaylistFormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".m3u":
+		return FormatM3U
+	case ".m3u8":
+		return FormatM3U8
+	case ".xspf":
+		return FormatXSPF
+	default:
+		return ""
+	}
+}
+
+// ReadPlaylist читает записи плейлиста M3U, M3U8 или XSPF и возвращает название плейлиста,
+// если оно указано в файле. Исполнитель и название берутся из #EXTINF или creator и title,
+// а если их нет - из имени файла в адресе записи вида "Исполнитель - Название.mp3".
+// M3U читается как UTF-8, как и M3U8.
+func ReadPlaylist(r io.Reader, format string) (string, []models.PlaylistEntry, error) {
+	switch format {
+	case FormatM3U, FormatM3U8:
+		return readM3U(r)
+	case FormatXSPF:
+		return readXSPF(r)
+	default:
+		return "", nil, fmt.Errorf("неизвестный формат плейлиста: %q, ожидается m3u, m3u8 или xspf", format)
+	}
+}
+
+// readM3U читает простой и расширенный M3U. Строка #EXTINF относится к следующей строке с адресом.
+func readM3U(r io.Reader) (string, []models.PlaylistEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var title, info string
+	entries := []models.PlaylistEntry{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info = extinfTitle(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			entry := models.PlaylistEntry{Index: len(entries) + 1, Location: line}
+			entry.Artist, entry.Title = splitArtistTitle(info)
+			if entry.Title == "" {
+				entry.Artist, entry.Title = splitArtistTitle(locationName(line))
+			}
+			entries = append(entries, entry)
+			info = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, fmt.Errorf("ошибка чтения плейлиста: %w", err)
+	}
+	return title, entries, nil
+}
+
+// extinfTitle возвращает отображаемое название из строки #EXTINF:длительность [атрибуты],название.
+// Значения атрибутов в кавычках могут содержать запятые.
+func extinfTitle(s string) string {
+	quoted := false
+	for i, c := range s {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				return strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+	return ""
+}
+
+// xspfPlaylist - документ XSPF, используются только название и треки
+type xspfPlaylist struct {
+	Title  string `xml:"title"`
+	Tracks []struct {
+		Location []string `xml:"location"`
+		Creator  string   `xml:"creator"`
+		Title    string   `xml:"title"`
+	} `xml:"trackList>track"`
+}
+
+// readXSPF читает плейлист XSPF. Если у трека несколько адресов, используется первый.
+func readXSPF(r io.Reader) (string, []models.PlaylistEntry, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return "", nil, fmt.Errorf("ошибка разбора XSPF: %w", err)
+	}
+
+	entries := make([]models.PlaylistEntry, 0, len(doc.Tracks))
+	for i, track := range doc.Tracks {
+		entry := models.PlaylistEntry{
+			Index:  i + 1,
+			Artist: strings.TrimSpace(track.Creator),
+			Title:  strings.TrimSpace(track.Title),
+		}
+		if len(track.Location) > 0 {
+			entry.Location = strings.TrimSpace(track.Location[0])
+		}
+		if entry.Artist == "" {
+			entry.Artist, entry.Title = splitArtistTitle(entry.Title)
+		}
+		if entry.Title == "" && entry.Location != "" {
+			entry.Artist, entry.Title = splitArtistTitle(locationName(entry.Location))
+		}
+		entries = append(entries, entry)
+	}
+	return strings.TrimSpace(doc.Title), entries, nil
+}
+
+// splitArtistTitle разделяет строку "Исполнитель - Название". Строка без разделителя
+// считается названием без исполнителя.
+func splitArtistTitle(s string) (string, string) {
+	for _, sep := range []string{" - ", " – ", " — "} {
+		if artist, title, ok := strings.Cut(s, sep); ok {
+			return strings.TrimSpace(artist), strings.TrimSpace(title)
+		}
+	}
+	return "", strings.TrimSpace(s)
+}
+
+// locationName возвращает имя файла из адреса записи без расширения.
+// Адрес может быть URL, путем Unix или путем Windows. Для адреса без расширения файла
+// (например, ссылки на страницу сервиса) возвращается пустая строка.
+func locationName(location string) string {
+	p := strings.ReplaceAll(location, `\`, "/")
+	if u, err := url.Parse(p); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		p = u.Path
+	}
+	name := path.Base(p)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	ext := path.Ext(name)
+	if ext == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, ext)
+}
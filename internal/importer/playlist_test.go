@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"music_library/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPlaylist(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		title   string
+		entries []models.PlaylistEntry
+	}{
+		{
+			name:   "расширенный M3U с запятыми в атрибутах EXTINF",
+			format: FormatM3U8,
+			input: "#EXTM3U\n" +
+				"#PLAYLIST:Рок\n" +
+				"#EXTINF:-1 tvg-name=\"Muse, live\" group-title=\"a,b\",Muse - Hysteria\n" +
+				"https://example.com/1\n",
+			title: "Рок",
+			entries: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Hysteria", Location: "https://example.com/1"},
+			},
+		},
+		{
+			name:   "BOM в начале файла и пустые строки",
+			format: FormatM3U,
+			input:  "\ufeff#EXTM3U\r\n\r\n#EXTINF:215,Muse - Uprising\r\nuprising.mp3\r\n",
+			entries: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Uprising", Location: "uprising.mp3"},
+			},
+		},
+		{
+			name:   "названия из имен файлов: путь Windows, путь Unix и URL",
+			format: FormatM3U,
+			input: `C:\Music\Muse\Muse - Starlight.mp3` + "\n" +
+				"/home/user/music/Muse – Madness.flac\n" +
+				"https://example.com/music/Muse%20-%20Resistance.mp3\n",
+			entries: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Starlight", Location: `C:\Music\Muse\Muse - Starlight.mp3`},
+				{Index: 2, Artist: "Muse", Title: "Madness", Location: "/home/user/music/Muse – Madness.flac"},
+				{Index: 3, Artist: "Muse", Title: "Resistance", Location: "https://example.com/music/Muse%20-%20Resistance.mp3"},
+			},
+		},
+		{
+			name:   "EXTINF относится только к следующей записи",
+			format: FormatM3U8,
+			input: "#EXTINF:-1,Muse - Hysteria\n" +
+				"https://example.com/1\n" +
+				"# комментарий\n" +
+				"https://example.com/watch\n",
+			entries: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Hysteria", Location: "https://example.com/1"},
+				{Index: 2, Location: "https://example.com/watch"},
+			},
+		},
+		{
+			name:   "XSPF с несколькими адресами трека",
+			format: FormatXSPF,
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title> Избранное </title>
+  <trackList>
+    <track>
+      <location>https://example.com/first</location>
+      <location>https://example.com/second</location>
+      <creator>Muse</creator>
+      <title>Uprising</title>
+    </track>
+    <track>
+      <title>Muse - Hysteria</title>
+    </track>
+    <track>
+      <location>file:///music/Muse%20-%20Starlight.ogg</location>
+    </track>
+  </trackList>
+</playlist>`,
+			title: "Избранное",
+			entries: []models.PlaylistEntry{
+				{Index: 1, Artist: "Muse", Title: "Uprising", Location: "https://example.com/first"},
+				{Index: 2, Artist: "Muse", Title: "Hysteria"},
+				{Index: 3, Artist: "Muse", Title: "Starlight", Location: "file:///music/Muse%20-%20Starlight.ogg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, entries, err := ReadPlaylist(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("ошибка чтения плейлиста: %v", err)
+			}
+			if title != tt.title {
+				t.Errorf("название %q, ожидалось %q", title, tt.title)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("записи %+v, ожидались %+v", entries, tt.entries)
+			}
+		})
+	}
+}
+
+func TestReadPlaylistErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{name: "неизвестный формат", format: "pls", input: "[playlist]"},
+		{name: "неверный XSPF", format: FormatXSPF, input: "<playlist><trackList>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ReadPlaylist(strings.NewReader(tt.input), tt.format); err == nil {
+				t.Fatal("ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestPlaylistFormatFromName(t *testing.T) {
+	tests := map[string]string{
+		"rock.m3u":       FormatM3U,
+		"Rock.M3U8":      FormatM3U8,
+		"list.xspf":      FormatXSPF,
+		"songs.csv":      "",
+		"без расширения": "",
+	}
+	for name, want := range tests {
+		if got := PlaylistFormatFromName(name); got != want {
+			t.Errorf("PlaylistFormatFromName(%q) = %q, ожидалось %q", name, got, want)
+		}
+	}
+}
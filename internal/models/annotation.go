@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AnnotationAnchor - привязка аннотации к диапазону символов [start, end) текста куплета.
+// Позиции считаются в символах, quote - текст диапазона на момент привязки.
+type AnnotationAnchor struct {
+	VerseNumber int    `json:"verse_number"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Quote       string `json:"quote"`
+}
+
+// Annotation - пояснение к фрагменту текста песни или ответ на него.
+// У ответа нет привязки, он относится к фрагменту аннотации верхнего уровня.
+// Orphaned означает, что после изменения текста фрагмент не найден и Anchor указывает прежнее положение.
+type Annotation struct {
+	ID        int               `json:"id"`
+	SongID    int               `json:"song_id"`
+	ParentID  *int              `json:"parent_id,omitempty"`
+	Anchor    *AnnotationAnchor `json:"anchor,omitempty"`
+	Orphaned  bool              `json:"orphaned"`
+	Body      string            `json:"body"`
+	Author    string            `json:"author"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Replies   []Annotation      `json:"replies,omitempty"`
+}
+
+// AnnotationRequest - запрос на создание аннотации к диапазону символов куплета
+type AnnotationRequest struct {
+	VerseNumber int    `json:"verse_number"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Body        string `json:"body"`
+}
+
+// AnnotationUpdate - запрос на изменение аннотации. Если указан verse_number,
+// аннотация привязывается к новому диапазону; у ответов привязку изменить нельзя.
+type AnnotationUpdate struct {
+	Body        string `json:"body"`
+	VerseNumber *int   `json:"verse_number,omitempty"`
+	Start       int    `json:"start,omitempty"`
+	End         int    `json:"end,omitempty"`
+}
+
+// ReplyRequest - запрос на ответ к аннотации
+type ReplyRequest struct {
+	Body string `json:"body"`
+}
+
+// VerseRequest - запрос на изменение текста куплета
+type VerseRequest struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Области действия ключей API
+const (
+	// ScopeRead разрешает чтение, как у роли viewer
+	ScopeRead = "read"
+	// ScopeWrite разрешает создание и изменение, как у роли editor, но без чтения
+	ScopeWrite = "write"
+)
+
+// APIKey - ключ API для доступа сервисов без входа пользователя. Сам ключ не хранится.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  *int       `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyRequest - запрос на создание ключа API. Без expires_at ключ действует до отзыва.
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate проверяет запрос на создание ключа API
+func (r APIKeyRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("не указано название ключа")
+	}
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("не указаны области действия ключа")
+	}
+	for _, scope := range r.Scopes {
+		switch scope {
+		case ScopeRead, ScopeWrite:
+		default:
+			return fmt.Errorf("недопустимая область действия %q, ожидается read или write", scope)
+		}
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("срок действия ключа уже истек")
+	}
+	return nil
+}
+
+// CreatedAPIKey - созданный ключ API. Key показывается только в ответе на создание.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
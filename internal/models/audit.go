@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Сущности журнала аудита
+const (
+	AuditEntitySong      = "song"
+	AuditEntityVerse     = "verse"
+	AuditEntityPlaylist  = "playlist"
+	AuditEntitySmartList = "smart_list"
+)
+
+// Действия журнала аудита
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditEntry - запись журнала аудита об изменении песни, ее куплетов или плейлиста.
+// Для куплетов EntityID - ID песни. Before отсутствует при создании, After - при удалении.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter - условия отбора записей журнала аудита. Пустые поля не ограничивают выборку,
+// границы периода включаются.
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	Actor    string
+	From     *time.Time
+	To       *time.Time
+}
+
+// PlaylistSnapshot - состояние плейлиста для журнала аудита: песни перечислены по порядку
+type PlaylistSnapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SongIDs     []int  `json:"song_ids"`
+}
+
+// SmartListSnapshot - состояние умного списка для журнала аудита
+type SmartListSnapshot struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Rules       SmartListRules `json:"rules"`
+}
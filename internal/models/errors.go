@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound возвращается, если запрошенная запись не существует
+var ErrNotFound = errors.New("запись не найдена")
+
+// ErrDuplicate возвращается, если такая песня уже есть в библиотеке
+var ErrDuplicate = errors.New("песня уже существует")
+
+// ErrInvalidInput возвращается, если входные данные запроса не прошли проверку
+var ErrInvalidInput = errors.New("неверные входные данные")
+
+// ErrUserExists возвращается, если пользователь с таким именем уже зарегистрирован
+var ErrUserExists = errors.New("пользователь уже существует")
+
+// ErrUnauthorized возвращается, если учетные данные или токен не прошли проверку
+var ErrUnauthorized = errors.New("ошибка аутентификации")
+
+// DuplicateSongError описывает найденный дубликат создаваемой песни
+type DuplicateSongError struct {
+	Existing   Song
+	Similarity float64
+}
+
+// Error возвращает описание ошибки
+func (e *DuplicateSongError) Error() string {
+	return fmt.Sprintf("%v: ID %d (%s - %s)", ErrDuplicate, e.Existing.ID, e.Existing.Group, e.Existing.Song)
+}
+
+// Is позволяет сравнивать ошибку с ErrDuplicate через errors.Is
+func (e *DuplicateSongError) Is(target error) bool {
+	return target == ErrDuplicate
+}
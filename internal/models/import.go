@@ -0,0 +1,119 @@
+package models
+
+import "fmt"
+
+// Результаты импорта строки
+const (
+	// ImportCreated - песня добавлена
+	ImportCreated = "created"
+	// ImportUpdated - у существующей песни изменены дата релиза, ссылка или куплеты
+	ImportUpdated = "updated"
+	// ImportUnchanged - песня уже есть в библиотеке с теми же данными
+	ImportUnchanged = "unchanged"
+	// ImportConflict - данные строки расходятся с данными существующей песни, песня не изменена
+	ImportConflict = "conflict"
+	// ImportSkipped - песня уже есть в библиотеке, в режиме ImportModeInsert она не изменяется
+	ImportSkipped = "skipped"
+	// ImportFailed - строку не удалось импортировать
+	ImportFailed = "failed"
+)
+
+// Режимы импорта
+const (
+	// ImportModeUpsert - новые песни добавляются, у существующих обновляются заполненные поля
+	ImportModeUpsert = "upsert"
+	// ImportModeInsert - добавляются только новые песни, существующие пропускаются
+	ImportModeInsert = "insert"
+)
+
+// ImportOptions - настройки импорта
+type ImportOptions struct {
+	// DryRun - проверить строки без сохранения
+	DryRun bool
+	// InsertOnly - только добавлять новые песни. Строки существующих песен получают результат ImportSkipped.
+	InsertOnly bool
+	// KeepExisting - не заменять заполненные поля существующих песен. Строки, данные которых
+	// расходятся с заполненными полями, получают результат ImportConflict.
+	KeepExisting bool
+}
+
+// SetMode задает режим импорта: ImportModeUpsert (по умолчанию, если mode пустой) или ImportModeInsert
+func (o *ImportOptions) SetMode(mode string) error {
+	switch mode {
+	case "", ImportModeUpsert:
+		o.InsertOnly = false
+	case ImportModeInsert:
+		o.InsertOnly = true
+	default:
+		return fmt.Errorf("%w: неизвестный режим импорта %q, ожидается upsert или insert", ErrInvalidInput, mode)
+	}
+	return nil
+}
+
+// ImportRow - строка файла импорта
+type ImportRow struct {
+	Line        int    `json:"-"`
+	Source      string `json:"-"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"date"`
+	Link        string `json:"link"`
+	Lyrics      string `json:"lyrics"`
+
+	// Verses - куплеты из Lyrics, заполняются сервисом перед сохранением
+	Verses []Verse `json:"-"`
+}
+
+// ImportRowResult - результат импорта одной строки
+type ImportRowResult struct {
+	Line   int    `json:"line"`
+	Source string `json:"source,omitempty"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Status string `json:"status"`
+	SongID int    `json:"song_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Conflicts - поля, значения которых расходятся с существующей песней
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// ImportReport - итог импорта: количество строк по результатам, ошибки и конфликты отдельных строк
+type ImportReport struct {
+	DryRun      bool              `json:"dry_run"`
+	Total       int               `json:"total"`
+	Created     int               `json:"created"`
+	Updated     int               `json:"updated"`
+	Unchanged   int               `json:"unchanged"`
+	Skipped     int               `json:"skipped"`
+	Conflicts   int               `json:"conflicts"`
+	Failed      int               `json:"failed"`
+	Errors      []ImportRowResult `json:"errors"`
+	Conflicting []ImportRowResult `json:"conflicting"`
+}
+
+// Add учитывает результат строки в итоге импорта. В итог включается не больше maxErrors
+// ошибочных и конфликтующих строк каждого вида.
+func (r *ImportReport) Add(result ImportRowResult, maxErrors int) {
+	r.Total++
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportConflict:
+		r.Conflicts++
+		if len(r.Conflicting) < maxErrors {
+			r.Conflicting = append(r.Conflicting, result)
+		}
+	default:
+		r.Failed++
+		if len(r.Errors) < maxErrors {
+			r.Errors = append(r.Errors, result)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы фоновых задач
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobKindCreateSong - задача асинхронного создания песни
+const JobKindCreateSong = "create_song"
+
+// Job представляет фоновую задачу и ее результат
+type Job struct {
+	ID         int             `db:"id" json:"id"`
+	Kind       string          `db:"kind" json:"kind"`
+	Status     string          `db:"status" json:"status"`
+	Payload    json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Result     json.RawMessage `db:"result" json:"result,omitempty" swaggertype:"object"`
+	Error      string          `db:"error" json:"error,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	StartedAt  *time.Time      `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt *time.Time      `db:"finished_at" json:"finished_at,omitempty"`
+
+	// Actor, RequestID и IP - инициатор и сведения о запросе, поставившем задачу, для журнала аудита.
+	// В ответ API не попадают.
+	Actor     string `db:"actor" json:"-"`
+	RequestID string `db:"request_id" json:"-"`
+	IP        string `db:"ip" json:"-"`
+}
+
+// CreateSongPayload - параметры задачи асинхронного создания песни
+type CreateSongPayload struct {
+	Song  Song `json:"song"`
+	Force bool `json:"force"`
+}
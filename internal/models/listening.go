@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Границы оценки песни
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Сортировки списка песен по статистике
+const (
+	// SongSortPopularity - по количеству прослушиваний, сначала самые популярные
+	SongSortPopularity = "popularity"
+	// SongSortRating - по средней оценке, сначала самые высокие, при равенстве - по количеству оценок
+	SongSortRating = "rating"
+)
+
+// SongStats - оценки, прослушивания и добавления в избранное песни всеми пользователями
+type SongStats struct {
+	AverageRating float64 `db:"average_rating" json:"average_rating"`
+	RatingCount   int     `db:"rating_count" json:"rating_count"`
+	PlayCount     int     `db:"play_count" json:"play_count"`
+	FavoriteCount int     `db:"favorite_count" json:"favorite_count"`
+}
+
+// Favorite - песня в избранном пользователя
+type Favorite struct {
+	Song    Song      `db:"song" json:"song"`
+	AddedAt time.Time `db:"added_at" json:"added_at"`
+}
+
+// FavoriteRequest - запрос на добавление песни в избранное
+type FavoriteRequest struct {
+	SongID int `json:"song_id"`
+}
+
+// SongRating - оценка песни пользователем
+type SongRating struct {
+	SongID  int       `db:"song_id" json:"song_id"`
+	Rating  int       `db:"rating" json:"rating"`
+	RatedAt time.Time `db:"rated_at" json:"rated_at"`
+}
+
+// RatingRequest - запрос на оценку песни от MinRating до MaxRating
+type RatingRequest struct {
+	Rating int `json:"rating"`
+}
+
+// Play - прослушивание песни пользователем
+type Play struct {
+	ID       int64     `db:"id" json:"id"`
+	Song     Song      `db:"song" json:"song"`
+	PlayedAt time.Time `db:"played_at" json:"played_at"`
+}
+
+// PlayRequest - запрос на запись прослушивания. Без played_at используется текущее время.
+type PlayRequest struct {
+	SongID   int        `json:"song_id"`
+	PlayedAt *time.Time `json:"played_at,omitempty"`
+}
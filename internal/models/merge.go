@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Правила разрешения конфликтов полей при слиянии песен
+const (
+	// MergeKeep оставляет значение песни, в которую выполняется слияние
+	MergeKeep = "keep"
+	// MergeReplace берет значение присоединяемой песни
+	MergeReplace = "replace"
+	// MergeFillEmpty берет значение присоединяемой песни, только если у сохраняемой оно пустое
+	MergeFillEmpty = "fill_empty"
+	// MergeAppend добавляет куплеты присоединяемой песни после куплетов сохраняемой
+	MergeAppend = "append"
+)
+
+// MergePolicy задает правило слияния для каждого поля песни.
+// Пустое правило означает MergeFillEmpty.
+type MergePolicy struct {
+	Group       string `json:"group,omitempty"`
+	Song        string `json:"song,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Verses      string `json:"verses,omitempty"`
+}
+
+// WithDefaults возвращает политику, в которой незаданные правила заменены на MergeFillEmpty
+func (p MergePolicy) WithDefaults() MergePolicy {
+	for _, rule := range []*string{&p.Group, &p.Song, &p.ReleaseDate, &p.Link, &p.Verses} {
+		if *rule == "" {
+			*rule = MergeFillEmpty
+		}
+	}
+	return p
+}
+
+// Validate проверяет, что для каждого поля указано допустимое правило
+func (p MergePolicy) Validate() error {
+	fields := map[string]string{"group": p.Group, "song": p.Song, "release_date": p.ReleaseDate, "link": p.Link}
+	for field, rule := range fields {
+		switch rule {
+		case "", MergeKeep, MergeReplace, MergeFillEmpty:
+		default:
+			return fmt.Errorf("недопустимое правило слияния %q для поля %s", rule, field)
+		}
+	}
+
+	switch p.Verses {
+	case "", MergeKeep, MergeReplace, MergeFillEmpty, MergeAppend:
+	default:
+		return fmt.Errorf("недопустимое правило слияния %q для куплетов", p.Verses)
+	}
+	return nil
+}
+
+// MergeRequest представляет запрос на слияние песни-дубликата с сохраняемой песней
+type MergeRequest struct {
+	SourceID int         `json:"source_id"`
+	Policy   MergePolicy `json:"policy"`
+}
+
+// SongMerge представляет запись журнала слияний песен
+type SongMerge struct {
+	ID             int          `json:"id"`
+	SurvivorID     int          `json:"survivor_id"`
+	MergedID       int          `json:"merged_id"`
+	Author         string       `json:"author"`
+	Policy         MergePolicy  `json:"policy"`
+	MergedSnapshot SongSnapshot `json:"merged_snapshot"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// SongMovedError возвращается при обращении к песне, которая была присоединена к другой песне
+type SongMovedError struct {
+	ID       int
+	TargetID int
+}
+
+// Error возвращает описание ошибки
+func (e *SongMovedError) Error() string {
+	return fmt.Sprintf("песня %d присоединена к песне %d", e.ID, e.TargetID)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Song представляет песню в музыкальной библиотеке
 type Song struct {
 	ID          int      `db:"id" json:"id"`
@@ -8,6 +10,40 @@ type Song struct {
 	ReleaseDate string   `db:"release_date" json:"release_date"`
 	Link        string   `db:"link" json:"link"`
 	Verses      []*Verse `db:"-" json:"verses"`
+
+	Provenance       DetailsProvenance `db:"provenance" json:"provenance"`
+	EnrichmentStatus string            `db:"enrichment_status" json:"enrichment_status,omitempty"`
+
+	// Stats заполняется только для отдельной песни и для списка, отсортированного по статистике
+	Stats *SongStats `db:"stats" json:"stats,omitempty"`
+}
+
+// Статусы получения информации о песне из внешних источников
+const (
+	// EnrichmentPending - информация еще не получена, песня ожидает повторной попытки
+	EnrichmentPending = "pending"
+	// EnrichmentEnriched - информация получена
+	EnrichmentEnriched = "enriched"
+	// EnrichmentFailed - информацию получить не удалось, попытки прекращены
+	EnrichmentFailed = "failed"
+)
+
+// EnrichmentTask - песня, для которой нужно повторить получение информации
+type EnrichmentTask struct {
+	SongID   int    `db:"id"`
+	Group    string `db:"group"`
+	Song     string `db:"song"`
+	Attempts int    `db:"enrichment_attempts"`
+}
+
+// SourceUser - источник данных, введенных пользователем
+const SourceUser = "user"
+
+// DetailsProvenance указывает, из какого источника получено каждое поле информации о песне
+type DetailsProvenance struct {
+	ReleaseDate string `db:"release_date" json:"release_date,omitempty"`
+	Text        string `db:"text" json:"text,omitempty"`
+	Link        string `db:"link" json:"link,omitempty"`
 }
 
 // SongDetails представляет информацию о песне из внешнего API
@@ -15,6 +51,9 @@ type SongDetails struct {
 	ReleaseDate string `json:"releaseDate"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
+
+	// Provenance заполняется источником информации и не передается внешним API
+	Provenance DetailsProvenance `json:"-"`
 }
 
 // Verse представляет куплет песни
@@ -24,3 +63,11 @@ type Verse struct {
 	VerseNumber int    `db:"verse_number" json:"verse_number"`
 	Text        string `db:"text" json:"text"`
 }
+
+// DetailsCacheEntry - сохраненный в кэше ответ источника информации о песне.
+// NotFound означает, что источник не знает песню (отрицательное кэширование).
+type DetailsCacheEntry struct {
+	Details   SongDetails
+	NotFound  bool
+	ExpiresAt time.Time
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Playlist представляет плейлист - упорядоченный список песен
+type Playlist struct {
+	ID          int            `db:"id" json:"id"`
+	Name        string         `db:"name" json:"name"`
+	Description string         `db:"description" json:"description"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+	Items       []PlaylistItem `db:"-" json:"items,omitempty"`
+}
+
+// PlaylistItem - элемент плейлиста. Одна песня может входить в плейлист несколько раз,
+// поэтому элементы различаются по собственному ID. Позиции идут подряд с единицы.
+type PlaylistItem struct {
+	ID       int       `db:"id" json:"id"`
+	Position int       `db:"position" json:"position"`
+	AddedAt  time.Time `db:"added_at" json:"added_at"`
+	Song     Song      `db:"song" json:"song"`
+}
+
+// PlaylistItemRequest - запрос на добавление песни в плейлист или перемещение элемента.
+// Нулевая позиция при добавлении означает конец плейлиста.
+type PlaylistItemRequest struct {
+	SongID   int `json:"song_id,omitempty"`
+	Position int `json:"position"`
+}
+
+// PlaylistEntry - запись файла плейлиста M3U или XSPF. Index - порядковый номер записи в файле,
+// SongID заполняется, если запись сопоставлена с песней библиотеки.
+type PlaylistEntry struct {
+	Index    int    `json:"index"`
+	Artist   string `json:"artist"`
+	Title    string `json:"title"`
+	Location string `json:"location,omitempty"`
+	SongID   int    `json:"song_id,omitempty"`
+}
+
+// PlaylistImportReport - итог импорта файла плейлиста
+type PlaylistImportReport struct {
+	DryRun     bool            `json:"dry_run"`
+	PlaylistID int             `json:"playlist_id,omitempty"`
+	Name       string          `json:"name"`
+	Total      int             `json:"total"`
+	Matched    []PlaylistEntry `json:"matched"`
+	Unmatched  []PlaylistEntry `json:"unmatched"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// RefreshResult описывает результат повторного получения информации о песне.
+// Changes содержит изменения, которые внесены (или были бы внесены при dry_run) в песню,
+// Overridden - поля, значение которых источник предлагает изменить, но пользователь задал его вручную.
+type RefreshResult struct {
+	SongID     int           `json:"song_id"`
+	Group      string        `json:"group"`
+	Song       string        `json:"song"`
+	Changes    []FieldChange `json:"changes"`
+	Overridden []string      `json:"overridden,omitempty"`
+	Applied    bool          `json:"applied"`
+	Error      string        `json:"error,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Действия, которые фиксируются в истории изменений песни
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionVerses  = "verses"
+	RevisionActionRevert  = "revert"
+	RevisionActionMerge   = "merge"
+	RevisionActionEnrich  = "enrich"
+	RevisionActionRefresh = "refresh"
+	RevisionActionImport  = "import"
+)
+
+// SongSnapshot представляет состояние песни и ее куплетов на момент ревизии
+type SongSnapshot struct {
+	Group       string  `json:"group"`
+	Song        string  `json:"song"`
+	ReleaseDate string  `json:"release_date"`
+	Link        string  `json:"link"`
+	Verses      []Verse `json:"verses"`
+}
+
+// FieldChange описывает изменение одного поля песни или куплета между ревизиями
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SongRevision представляет ревизию песни: снимок состояния и отличия от предыдущей ревизии
+type SongRevision struct {
+	ID        int           `json:"id"`
+	SongID    int           `json:"song_id"`
+	Revision  int           `json:"revision"`
+	Author    string        `json:"author"`
+	Action    string        `json:"action"`
+	Snapshot  SongSnapshot  `json:"snapshot"`
+	Diff      []FieldChange `json:"diff"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Типы изменений строк текста песни
+const (
+	LineAdded   = "added"
+	LineRemoved = "removed"
+	LineChanged = "changed"
+)
+
+// LineChange описывает изменение одной строки куплета.
+// Номера строк начинаются с единицы, 0 означает отсутствие строки в соответствующей ревизии.
+type LineChange struct {
+	Type    string `json:"type"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+// VerseDiff описывает построчные изменения одного куплета
+type VerseDiff struct {
+	VerseNumber int          `json:"verse_number"`
+	Lines       []LineChange `json:"lines"`
+}
+
+// LyricsDiff представляет построчные отличия текста песни между двумя ревизиями
+type LyricsDiff struct {
+	SongID  int         `json:"song_id"`
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Verses  []VerseDiff `json:"verses"`
+	Unified string      `json:"unified"`
+}
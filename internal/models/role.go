@@ -0,0 +1,84 @@
+package models
+
+import "fmt"
+
+// Роли пользователей
+const (
+	// RoleViewer может только читать
+	RoleViewer = "viewer"
+	// RoleEditor может также создавать и изменять песни, куплеты и плейлисты
+	RoleEditor = "editor"
+	// RoleAdmin может также удалять и объединять песни и управлять пользователями и ключами API
+	RoleAdmin = "admin"
+)
+
+// Permission - право на группу действий
+type Permission string
+
+// Права доступа
+const (
+	// PermRead - чтение библиотеки
+	PermRead Permission = "read"
+	// PermEdit - создание и изменение песен, куплетов, плейлистов и умных списков
+	PermEdit Permission = "edit"
+	// PermDelete - удаление и слияние песен, удаление плейлистов и умных списков
+	PermDelete Permission = "delete"
+	// PermAdmin - управление пользователями и ключами API
+	PermAdmin Permission = "admin"
+)
+
+// rolePermissions - права каждой роли
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {PermRead},
+	RoleEditor: {PermRead, PermEdit},
+	RoleAdmin:  {PermRead, PermEdit, PermDelete, PermAdmin},
+}
+
+// scopePermissions - права областей действия ключей API. Ключи не могут удалять данные
+// и управлять доступом.
+var scopePermissions = map[string][]Permission{
+	ScopeRead:  {PermRead},
+	ScopeWrite: {PermEdit},
+}
+
+// anonymousPermissions - права анонимных запросов, если вход не обязателен. Удаление
+// и управление доступом требуют входа всегда.
+var anonymousPermissions = []Permission{PermRead, PermEdit}
+
+// ValidateRole проверяет название роли
+func ValidateRole(role string) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return fmt.Errorf("недопустимая роль %q, ожидается viewer, editor или admin", role)
+	}
+	return nil
+}
+
+// Can проверяет, что роли пользователя выдано право perm
+func (u User) Can(perm Permission) bool {
+	return hasPermission(rolePermissions[u.Role], perm)
+}
+
+// Can проверяет, что областям действия ключа API выдано право perm
+func (k APIKey) Can(perm Permission) bool {
+	for _, scope := range k.Scopes {
+		if hasPermission(scopePermissions[scope], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnonymousCan проверяет, что право perm выдано анонимным запросам
+func AnonymousCan(perm Permission) bool {
+	return hasPermission(anonymousPermissions, perm)
+}
+
+// hasPermission проверяет наличие права в списке
+func hasPermission(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Поля сортировки умного списка
+const (
+	SmartSortID          = "id"
+	SmartSortGroup       = "group"
+	SmartSortSong        = "song"
+	SmartSortReleaseDate = "release_date"
+)
+
+// MaxSmartListLimit - максимальное количество песен, которое может задавать правило умного списка
+const MaxSmartListLimit = 10000
+
+// SmartListRules - правило умного списка. Поля group, song, release_date, link и enrichment_status
+// совпадают с фильтрами GET /songs, released_after и released_before ограничивают дату выпуска
+// (год YYYY или дата DD.MM.YYYY, границы не включаются), sort и desc задают порядок песен,
+// limit - наибольшее количество песен в списке.
+type SmartListRules struct {
+	Group            string `json:"group,omitempty"`
+	Song             string `json:"song,omitempty"`
+	ReleaseDate      string `json:"release_date,omitempty"`
+	Link             string `json:"link,omitempty"`
+	EnrichmentStatus string `json:"enrichment_status,omitempty"`
+
+	ReleasedAfter  string `json:"released_after,omitempty"`
+	ReleasedBefore string `json:"released_before,omitempty"`
+	Sort           string `json:"sort,omitempty"`
+	Desc           bool   `json:"desc,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+}
+
+// Filter возвращает фильтр списка песен, общий с GET /songs
+func (r SmartListRules) Filter() Song {
+	return Song{
+		Group:            r.Group,
+		Song:             r.Song,
+		ReleaseDate:      r.ReleaseDate,
+		Link:             r.Link,
+		EnrichmentStatus: r.EnrichmentStatus,
+	}
+}
+
+// Validate проверяет правило умного списка
+func (r SmartListRules) Validate() error {
+	switch r.EnrichmentStatus {
+	case "", EnrichmentPending, EnrichmentEnriched, EnrichmentFailed:
+	default:
+		return fmt.Errorf("недопустимый статус получения информации %q", r.EnrichmentStatus)
+	}
+
+	switch r.Sort {
+	case "", SmartSortID, SmartSortGroup, SmartSortSong, SmartSortReleaseDate:
+	default:
+		return fmt.Errorf("недопустимое поле сортировки %q, ожидается id, group, song или release_date", r.Sort)
+	}
+
+	if r.Limit < 0 || r.Limit > MaxSmartListLimit {
+		return fmt.Errorf("количество песен должно быть от 0 до %d", MaxSmartListLimit)
+	}
+
+	after, err := ReleaseDateBound(r.ReleasedAfter, true)
+	if err != nil {
+		return fmt.Errorf("released_after: %w", err)
+	}
+	before, err := ReleaseDateBound(r.ReleasedBefore, false)
+	if err != nil {
+		return fmt.Errorf("released_before: %w", err)
+	}
+	if after != "" && before != "" && after >= before {
+		return fmt.Errorf("released_after должна быть раньше released_before")
+	}
+	return nil
+}
+
+// releaseDatePattern - формат даты выпуска песни
+var releaseDatePattern = regexp.MustCompile(`^(\d{2})\.(\d{2})\.(\d{4})$`)
+
+// ReleaseDateBound преобразует границу даты выпуска (год YYYY или дата DD.MM.YYYY) в ключ YYYYMMDD,
+// который можно сравнивать как строку. Граница-год включает весь год: для after ключом служит
+// последний день года, для before - первый. Пустая граница дает пустой ключ.
+func ReleaseDateBound(value string, after bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if year, err := strconv.Atoi(value); err == nil && len(value) == 4 && year > 0 {
+		if after {
+			return value + "1231", nil
+		}
+		return value + "0101", nil
+	}
+
+	m := releaseDatePattern.FindStringSubmatch(value)
+	if m == nil {
+		return "", fmt.Errorf("неверная дата %q, ожидается YYYY или DD.MM.YYYY", value)
+	}
+	if _, err := time.Parse("02.01.2006", value); err != nil {
+		return "", fmt.Errorf("неверная дата %q", value)
+	}
+	return m[3] + m[2] + m[1], nil
+}
+
+// SmartList - умный список: сохраненное правило отбора песен, которое применяется при каждом чтении
+type SmartList struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Rules       SmartListRules `json:"rules"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Songs       []Song         `json:"songs,omitempty"`
+}
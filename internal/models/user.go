@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// User представляет учетную запись пользователя
+type User struct {
+	ID           int       `db:"id" json:"id"`
+	Username     string    `db:"username" json:"username"`
+	PasswordHash string    `db:"password_hash" json:"-"`
+	Role         string    `db:"role" json:"role"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// UserRequest - запрос администратора на создание пользователя или изменение его роли.
+// При изменении роли имя и пароль не используются.
+type UserRequest struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Role     string `json:"role"`
+}
+
+// LoginRequest - запрос на вход по имени пользователя и паролю
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest - запрос на обновление токена доступа или выход
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenPair - выданные при входе или обновлении токены. ExpiresIn - время жизни
+// токена доступа в секундах. Токен обновления одноразовый: при обновлении выдается новый.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
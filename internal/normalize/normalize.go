@@ -0,0 +1,62 @@
+// Package normalize приводит названия групп, песен и тексты к виду,
+// пригодному для поиска дубликатов.
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillic содержит транслитерацию кириллических букв в латиницу
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+}
+
+// Key возвращает нормализованный ключ названия группы или песни:
+// нижний регистр, латиница, без диакритики и пунктуации, без артикля "the" в начале.
+// Например, "The Beatles" и "beatles!" дают одинаковый ключ.
+func Key(s string) string {
+	words := Words(s)
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Words разбивает текст на нормализованные слова
+func Words(s string) []string {
+	s = transliterate(strings.ToLower(s))
+	s = strings.ReplaceAll(s, "&", " and ")
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// transliterate удаляет диакритику и переводит кириллицу в латиницу
+func transliterate(s string) string {
+	// ё и й раскладываются в NFD на букву и диакритику, поэтому транслитерация выполняется до удаления знаков
+	var sb strings.Builder
+	for _, r := range s {
+		if latin, ok := cyrillic[r]; ok {
+			sb.WriteString(latin)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, sb.String())
+	if err != nil {
+		return sb.String()
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается без обращения к внешнему API, пока размыкатель разомкнут
+var ErrCircuitOpen = errors.New("внешний API недоступен, размыкатель разомкнут")
+
+// Состояния размыкателя
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerConfig описывает настройки размыкателя
+type BreakerConfig struct {
+	// FailureThreshold - количество ошибок подряд, после которого размыкатель размыкается
+	FailureThreshold int
+	// OpenTimeout - время, через которое разомкнутый размыкатель пропускает пробный запрос
+	OpenTimeout time.Duration
+}
+
+// BreakerStatus описывает текущее состояние размыкателя для диагностики
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+}
+
+// CircuitBreaker прекращает обращения к недоступному внешнему API.
+// После FailureThreshold ошибок подряд запросы отклоняются в течение OpenTimeout,
+// затем пропускается один пробный запрос: при успехе размыкатель замыкается, при ошибке снова размыкается.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker создает замкнутый размыкатель
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg, now: time.Now, state: BreakerClosed}
+}
+
+// Allow проверяет, можно ли выполнить запрос. Возвращает ErrCircuitOpen, если размыкатель разомкнут.
+func (b *CircuitBreaker) Allow() error {
+	if b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		// Пока пробный запрос не завершился, остальные запросы отклоняются
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success сообщает об успешном запросе и замыкает размыкатель
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure сообщает о неуспешном запросе
+func (b *CircuitBreaker) Failure() {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Abort сообщает, что запрос прерван вызывающей стороной и не говорит о состоянии внешнего API
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Status возвращает текущее состояние размыкателя
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cfg.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
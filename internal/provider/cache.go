@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Виды кэша информации о песнях
+const (
+	CacheNone     = "none"
+	CacheMemory   = "memory"
+	CachePostgres = "postgres"
+)
+
+// DetailsCache - хранилище ответов источника информации о песнях
+type DetailsCache interface {
+	// Get возвращает запись по ключу. Второе значение равно false, если записи нет или она устарела.
+	Get(ctx context.Context, key string) (models.DetailsCacheEntry, bool, error)
+
+	// Set сохраняет запись по ключу
+	Set(ctx context.Context, key string, entry models.DetailsCacheEntry) error
+}
+
+// CacheConfig описывает настройки кэша информации о песнях
+type CacheConfig struct {
+	// Kind - вид кэша: none, memory или postgres. По умолчанию кэш отключен.
+	Kind string
+	// TTL - время жизни найденной информации
+	TTL time.Duration
+	// NegativeTTL - время жизни ответа "песня не найдена"
+	NegativeTTL time.Duration
+	// Size - максимальное количество записей кэша в памяти
+	Size int
+	// CleanupInterval - период удаления устаревших записей из кэша в PostgreSQL
+	CleanupInterval time.Duration
+}
+
+// ExpiringCache - хранилище, из которого нужно периодически удалять устаревшие записи.
+// Кэш в памяти удаляет их сам при чтении и вытеснении.
+type ExpiringCache interface {
+	// DeleteExpired удаляет устаревшие записи и возвращает их количество
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+// RunCacheCleanup удаляет устаревшие записи кэша каждые interval, пока не отменен контекст
+func RunCacheCleanup(ctx context.Context, cache ExpiringCache, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := cache.DeleteExpired(ctx)
+		if err != nil {
+			log.Printf("Ошибка удаления устаревших записей кэша информации о песнях: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Удалено устаревших записей кэша информации о песнях: %d", n)
+		}
+	}
+}
+
+// CacheStats содержит счетчики обращений к кэшу
+type CacheStats struct {
+	Hits         int64 `json:"hits"`
+	NegativeHits int64 `json:"negative_hits"`
+	Misses       int64 `json:"misses"`
+	Errors       int64 `json:"errors"`
+}
+
+// CachingProvider кэширует ответы другого источника информации о песнях.
+// Ошибки источника, кроме "песня не найдена", не кэшируются.
+type CachingProvider struct {
+	next  DetailsProvider
+	cache DetailsCache
+	cfg   CacheConfig
+	now   func() time.Time
+
+	hits         atomic.Int64
+	negativeHits atomic.Int64
+	misses       atomic.Int64
+	errors       atomic.Int64
+}
+
+// NewCachingProvider создает источник, кэширующий ответы next
+func NewCachingProvider(next DetailsProvider, cache DetailsCache, cfg CacheConfig) *CachingProvider {
+	return &CachingProvider{next: next, cache: cache, cfg: cfg, now: time.Now}
+}
+
+// GetSongDetails возвращает информацию о песне из кэша или запрашивает ее у источника
+func (p *CachingProvider) GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error) {
+	key := detailsKey(group, song)
+
+	if !bypassCache(ctx) {
+		entry, ok, err := p.cache.Get(ctx, key)
+		switch {
+		case err != nil:
+			// Кэш недоступен: информация запрашивается у источника
+			p.errors.Add(1)
+			log.Printf("Ошибка чтения кэша информации о песнях: %v", err)
+		case ok && entry.NotFound:
+			p.negativeHits.Add(1)
+			return models.SongDetails{}, fmt.Errorf("песня %s - %s (из кэша): %w", group, song, models.ErrNotFound)
+		case ok:
+			p.hits.Add(1)
+			return entry.Details, nil
+		}
+	}
+	p.misses.Add(1)
+
+	details, err := p.next.GetSongDetails(ctx, group, song)
+	entry := models.DetailsCacheEntry{Details: details, ExpiresAt: p.now().Add(p.cfg.TTL)}
+	if IsNotFound(err) {
+		entry = models.DetailsCacheEntry{NotFound: true, ExpiresAt: p.now().Add(p.cfg.NegativeTTL)}
+	} else if err != nil {
+		return models.SongDetails{}, err
+	}
+
+	if setErr := p.cache.Set(ctx, key, entry); setErr != nil {
+		p.errors.Add(1)
+		log.Printf("Ошибка записи в кэш информации о песнях: %v", setErr)
+	}
+	return details, err
+}
+
+// Status возвращает состояние кэшируемого источника для диагностики
+func (p *CachingProvider) Status() []ProviderStatus {
+	if reporter, ok := p.next.(StatusReporter); ok {
+		return reporter.Status()
+	}
+	return nil
+}
+
+// CacheStats возвращает счетчики обращений к кэшу
+func (p *CachingProvider) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:         p.hits.Load(),
+		NegativeHits: p.negativeHits.Load(),
+		Misses:       p.misses.Load(),
+		Errors:       p.errors.Load(),
+	}
+}
+
+// IsNotFound проверяет, что песня неизвестна источнику. Для объединенной ошибки цепочки
+// все источники должны ответить "не найдено": при временной ошибке одного из них
+// информацию нужно запросить повторно, а не считать песню неизвестной.
+func IsNotFound(err error) bool {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		for _, inner := range errs {
+			if !IsNotFound(inner) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return IsNotFound(inner)
+		}
+	}
+	return errors.Is(err, models.ErrNotFound)
+}
+
+// bypassKey - ключ контекста, отключающий чтение из кэша
+type bypassKey struct{}
+
+// WithoutCache возвращает контекст, в котором информация всегда запрашивается у источника.
+// Полученный ответ по-прежнему сохраняется в кэш.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// bypassCache проверяет, отключено ли чтение из кэша для запроса
+func bypassCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// LRUCache хранит ограниченное количество записей в памяти
+// и вытесняет записи, к которым дольше всего не обращались
+type LRUCache struct {
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruItem - элемент списка LRUCache
+type lruItem struct {
+	key   string
+	entry models.DetailsCacheEntry
+}
+
+// NewLRUCache создает кэш в памяти на size записей
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    max(size, 1),
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get возвращает запись по ключу, устаревшая запись удаляется
+func (c *LRUCache) Get(ctx context.Context, key string) (models.DetailsCacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return models.DetailsCacheEntry{}, false, nil
+	}
+
+	item := elem.Value.(*lruItem)
+	if !c.now().Before(item.entry.ExpiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return models.DetailsCacheEntry{}, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+	return item.entry, true, nil
+}
+
+// Set сохраняет запись и при переполнении вытесняет самую давнюю
+func (c *LRUCache) Set(ctx context.Context, key string, entry models.DetailsCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+	return nil
+}
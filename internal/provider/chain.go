@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"net/http"
+	"strings"
+)
+
+// Поля информации о песне, для которых задается порядок источников
+const (
+	FieldReleaseDate = "release_date"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+// NamedProvider - источник информации о песнях с именем, которое записывается в происхождение полей
+type NamedProvider struct {
+	Name     string
+	Provider DetailsProvider
+}
+
+// Chain опрашивает несколько источников и объединяет их ответы по полям.
+// Для каждого поля берется первое непустое значение в порядке источников этого поля.
+type Chain struct {
+	providers  []NamedProvider
+	precedence map[string][]string
+}
+
+// NewChain создает цепочку источников. Порядок источников для отдельных полей
+// задается в precedence, для остальных полей используется порядок providers.
+func NewChain(providers []NamedProvider, precedence map[string][]string) (*Chain, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("цепочка источников информации пуста")
+	}
+
+	names := make(map[string]bool, len(providers))
+	order := make([]string, 0, len(providers))
+	for _, p := range providers {
+		if p.Name == "" || names[p.Name] {
+			return nil, fmt.Errorf("имя источника информации пустое или повторяется: %q", p.Name)
+		}
+		names[p.Name] = true
+		order = append(order, p.Name)
+	}
+
+	resolved := make(map[string][]string, 3)
+	for _, field := range []string{FieldReleaseDate, FieldText, FieldLink} {
+		resolved[field] = order
+	}
+	for field, fieldOrder := range precedence {
+		if _, ok := resolved[field]; !ok {
+			return nil, fmt.Errorf("неизвестное поле в порядке источников: %q", field)
+		}
+		for _, name := range fieldOrder {
+			if !names[name] {
+				return nil, fmt.Errorf("неизвестный источник %q в порядке для поля %s", name, field)
+			}
+		}
+		resolved[field] = fieldOrder
+	}
+
+	return &Chain{providers: providers, precedence: resolved}, nil
+}
+
+// GetSongDetails опрашивает все источники и объединяет их ответы.
+// Ошибка возвращается, только если ни один источник не ответил.
+func (c *Chain) GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error) {
+	results := make(map[string]models.SongDetails, len(c.providers))
+	var errs []error
+	for _, p := range c.providers {
+		details, err := p.Provider.GetSongDetails(ctx, group, song)
+		if err != nil {
+			if !errors.Is(err, models.ErrNotFound) {
+				log.Printf("Ошибка источника информации %s: %v", p.Name, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+		results[p.Name] = details
+	}
+
+	if len(results) == 0 {
+		return models.SongDetails{}, errors.Join(errs...)
+	}
+
+	var merged models.SongDetails
+	merged.ReleaseDate, merged.Provenance.ReleaseDate = c.pick(FieldReleaseDate, results,
+		func(d models.SongDetails) string { return d.ReleaseDate })
+	merged.Text, merged.Provenance.Text = c.pick(FieldText, results,
+		func(d models.SongDetails) string { return d.Text })
+	merged.Link, merged.Provenance.Link = c.pick(FieldLink, results,
+		func(d models.SongDetails) string { return d.Link })
+
+	return merged, nil
+}
+
+// Status возвращает состояние источников цепочки.
+// Для источников с размыкателем включается его состояние.
+func (c *Chain) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, 0, len(c.providers))
+	for _, p := range c.providers {
+		status := ProviderStatus{Name: p.Name}
+		if reporter, ok := p.Provider.(interface{ BreakerStatus() BreakerStatus }); ok {
+			breaker := reporter.BreakerStatus()
+			status.Breaker = &breaker
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// pick возвращает первое непустое значение поля и имя источника, который его предоставил
+func (c *Chain) pick(field string, results map[string]models.SongDetails, get func(models.SongDetails) string) (string, string) {
+	for _, name := range c.precedence[field] {
+		details, ok := results[name]
+		if !ok {
+			continue
+		}
+		if value := get(details); value != "" {
+			return value, name
+		}
+	}
+	return "", ""
+}
+
+// ParseChain разбирает описание цепочки источников вида
+// "override=file:./override.json,catalog=file:./catalog.csv,api=info".
+// Источники внешних API используют общий HTTP-клиент client.
+func ParseChain(spec string, cfg Config, client *http.Client) ([]NamedProvider, error) {
+	var providers []NamedProvider
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, source, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("неверное описание источника %q, ожидается имя=вид[:путь]", item)
+		}
+		kind, path, _ := strings.Cut(source, ":")
+
+		single := cfg
+		single.Kind, single.FilePath = kind, path
+		p, err := newSingle(single, client)
+		if err != nil {
+			return nil, fmt.Errorf("источник %s: %w", name, err)
+		}
+		providers = append(providers, NamedProvider{Name: strings.TrimSpace(name), Provider: p})
+	}
+	return providers, nil
+}
+
+// ParsePrecedence разбирает порядок источников для полей вида
+// "release_date=override,catalog,api;text=api,catalog"
+func ParsePrecedence(spec string) (map[string][]string, error) {
+	precedence := make(map[string][]string)
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		field, list, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("неверное описание порядка источников %q, ожидается поле=источник,...", item)
+		}
+
+		var names []string
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		precedence[strings.TrimSpace(field)] = names
+	}
+	return precedence, nil
+}
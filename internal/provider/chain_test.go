@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"testing"
+)
+
+// stubProvider - источник информации, который всегда отвечает заданной ошибкой
+type stubProvider struct {
+	err error
+}
+
+// GetSongDetails возвращает ошибку источника
+func (p stubProvider) GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error) {
+	return models.SongDetails{}, p.err
+}
+
+func TestIsNotFoundChainErrors(t *testing.T) {
+	notFound := stubProvider{err: fmt.Errorf("песня не найдена: %w", models.ErrNotFound)}
+	unavailable := stubProvider{err: errors.New("таймаут запроса")}
+
+	tests := []struct {
+		name      string
+		providers []NamedProvider
+		want      bool
+	}{
+		{
+			name:      "все источники не нашли песню",
+			providers: []NamedProvider{{Name: "api", Provider: notFound}, {Name: "file", Provider: notFound}},
+			want:      true,
+		},
+		{
+			name:      "один источник не нашел песню, другой недоступен",
+			providers: []NamedProvider{{Name: "api", Provider: unavailable}, {Name: "file", Provider: notFound}},
+			want:      false,
+		},
+		{
+			name:      "недоступный источник после не нашедшего песню",
+			providers: []NamedProvider{{Name: "file", Provider: notFound}, {Name: "api", Provider: unavailable}},
+			want:      false,
+		},
+		{
+			name:      "все источники недоступны",
+			providers: []NamedProvider{{Name: "api", Provider: unavailable}, {Name: "file", Provider: unavailable}},
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewChain(tt.providers, nil)
+			if err != nil {
+				t.Fatalf("ошибка создания цепочки: %v", err)
+			}
+			_, err = chain.GetSongDetails(context.Background(), "Muse", "Resistance")
+			if err == nil {
+				t.Fatal("ожидалась ошибка цепочки")
+			}
+			if got := IsNotFound(err); got != tt.want {
+				t.Errorf("IsNotFound(%v) = %v, ожидалось %v", err, got, tt.want)
+			}
+			// Обертка ошибки не меняет результат
+			if got := IsNotFound(fmt.Errorf("ошибка получения информации: %w", err)); got != tt.want {
+				t.Errorf("IsNotFound для обернутой ошибки = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileEntry - запись JSON-файла с информацией о песне
+type FileEntry struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	models.SongDetails
+}
+
+// FileProvider получает информацию о песне из статического файла каталога.
+// JSON-файл содержит массив записей FileEntry, CSV-файл - строку заголовка со столбцами
+// group, song, release_date, text, link. Файл читается один раз при создании.
+type FileProvider struct {
+	entries map[string]models.SongDetails
+}
+
+// NewFileProvider создает FileProvider из JSON- или CSV-файла, формат определяется по расширению
+func NewFileProvider(path string) (*FileProvider, error) {
+	var (
+		entries []FileEntry
+		err     error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		entries, err = readCSVEntries(path)
+	} else {
+		entries, err = readJSONEntries(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &FileProvider{entries: make(map[string]models.SongDetails, len(entries))}
+	for _, entry := range entries {
+		p.entries[detailsKey(entry.Group, entry.Song)] = entry.SongDetails
+	}
+	return p, nil
+}
+
+// GetSongDetails ищет песню в файле по нормализованным названиям группы и песни
+func (p *FileProvider) GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error) {
+	details, ok := p.entries[detailsKey(group, song)]
+	if !ok {
+		return models.SongDetails{}, fmt.Errorf("песня %s - %s в файле: %w", group, song, models.ErrNotFound)
+	}
+	return details, nil
+}
+
+// detailsKey возвращает ключ поиска песни, не зависящий от регистра и пунктуации.
+// Нормализованные названия состоят из букв, цифр и пробелов, поэтому разделитель "|" однозначен.
+// Ключ хранится в столбце TEXT кэша в PostgreSQL, где недопустим нулевой байт.
+func detailsKey(group, song string) string {
+	return normalize.Key(group) + "|" + normalize.Key(song)
+}
+
+// readJSONEntries читает записи каталога из JSON-файла
+func readJSONEntries(path string) ([]FileEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла с информацией о песнях: %w", err)
+	}
+
+	var entries []FileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования файла с информацией о песнях: %w", err)
+	}
+	return entries, nil
+}
+
+// readCSVEntries читает записи каталога из CSV-файла со строкой заголовка
+func readCSVEntries(path string) ([]FileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла с информацией о песнях: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка декодирования файла с информацией о песнях: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"group", "song"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("в файле %s нет столбца %s", path, required)
+		}
+	}
+
+	value := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	entries := make([]FileEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entries = append(entries, FileEntry{
+			Group: value(record, "group"),
+			Song:  value(record, "song"),
+			SongDetails: models.SongDetails{
+				ReleaseDate: value(record, "release_date"),
+				Text:        value(record, "text"),
+				Link:        value(record, "link"),
+			},
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// HTTPConfig описывает общий HTTP-клиент для запросов к внешним сервисам
+type HTTPConfig struct {
+	// Timeout - общее время запроса, включая чтение ответа
+	Timeout time.Duration
+	// DialTimeout - время установки TCP-соединения
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout - время TLS-рукопожатия
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout - время ожидания заголовков ответа
+	ResponseHeaderTimeout time.Duration
+	// MaxIdleConns и MaxIdleConnsPerHost - размер пула простаивающих соединений
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout - время, после которого простаивающее соединение закрывается
+	IdleConnTimeout time.Duration
+	// CAFile - PEM-файл с дополнительными корневыми сертификатами
+	CAFile string
+	// UserAgent - значение заголовка User-Agent
+	UserAgent string
+	// MaxResponseBytes - максимальный размер тела ответа
+	MaxResponseBytes int64
+	// Debug включает журналирование запросов и ответов, секреты в адресах и заголовках скрываются
+	Debug bool
+}
+
+// redacted - значение, которым в журнале заменяются секреты
+const redacted = "REDACTED"
+
+// sensitiveParams - параметры запроса, значения которых не попадают в журнал
+var sensitiveParams = []string{"key", "api_key", "apikey", "token", "access_token", "secret", "password", "signature"}
+
+// sensitiveHeaders - заголовки, значения которых не попадают в журнал
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// NewHTTPClient создает HTTP-клиент с таймаутами, пулом соединений и, если задан, дополнительным
+// набором корневых сертификатов. Прокси берется из переменных HTTP_PROXY, HTTPS_PROXY и NO_PROXY.
+func NewHTTPClient(cfg HTTPConfig) (*http.Client, error) {
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла сертификатов: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("в файле %s нет сертификатов в формате PEM", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &outboundTransport{next: transport, userAgent: cfg.UserAgent, debug: cfg.Debug},
+	}, nil
+}
+
+// outboundTransport добавляет заголовок User-Agent и журналирует запросы в режиме отладки
+type outboundTransport struct {
+	next      http.RoundTripper
+	userAgent string
+	debug     bool
+}
+
+// RoundTrip выполняет запрос
+func (t *outboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if !t.debug {
+		return t.next.RoundTrip(req)
+	}
+
+	log.Printf("HTTP-запрос: %s %s, заголовки: %v", req.Method, RedactURL(req.URL), redactHeaders(req.Header))
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		log.Printf("HTTP-запрос %s %s завершился ошибкой за %s: %v", req.Method, RedactURL(req.URL), time.Since(start), err)
+		return nil, err
+	}
+	log.Printf("HTTP-ответ: %s %s, статус %d за %s, размер %d, заголовки: %v",
+		req.Method, RedactURL(req.URL), resp.StatusCode, time.Since(start), resp.ContentLength, redactHeaders(resp.Header))
+	return resp, nil
+}
+
+// RedactURL возвращает адрес для журнала: пароль и значения секретных параметров запроса скрываются
+func RedactURL(u *url.URL) string {
+	redactedURL := *u
+	if _, ok := u.User.Password(); ok {
+		redactedURL.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		for _, sensitive := range sensitiveParams {
+			if strings.EqualFold(name, sensitive) {
+				query.Set(name, redacted)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL.String()
+}
+
+// redactHeaders возвращает копию заголовков, в которой значения секретных заголовков скрыты
+func redactHeaders(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if clone.Get(name) != "" {
+			clone.Set(name, redacted)
+		}
+	}
+	return clone
+}
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"music_library/internal/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryConfig описывает повторные попытки запросов к внешнему API
+type RetryConfig struct {
+	// MaxAttempts - максимальное количество попыток, включая первую
+	MaxAttempts int
+	// BaseDelay - задержка перед первой повторной попыткой, далее удваивается
+	BaseDelay time.Duration
+	// MaxDelay - максимальная задержка между попытками
+	MaxDelay time.Duration
+	// MaxRetryAfter - максимальное ожидание по заголовку Retry-After.
+	// Если API просит подождать дольше, запрос завершается ошибкой.
+	MaxRetryAfter time.Duration
+}
+
+// retryableError - ошибка запроса, после которой имеет смысл повторить попытку
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// InfoAPIProvider получает информацию о песне из внешнего API по адресу /info.
+// Неуспешные запросы повторяются с экспоненциальной задержкой,
+// а при недоступности API запросы отклоняются размыкателем.
+type InfoAPIProvider struct {
+	baseURL  string
+	client   *http.Client
+	maxBytes int64
+	retry    RetryConfig
+	breaker  *CircuitBreaker
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewInfoAPIProvider создает новый InfoAPIProvider. Ответы больше maxBytes считаются ошибкой,
+// 0 снимает ограничение.
+func NewInfoAPIProvider(baseURL string, client *http.Client, maxBytes int64, retry RetryConfig, breaker BreakerConfig) *InfoAPIProvider {
+	return &InfoAPIProvider{
+		baseURL:  baseURL,
+		client:   client,
+		maxBytes: maxBytes,
+		retry:    retry,
+		breaker:  NewCircuitBreaker(breaker),
+		sleep:    sleep,
+	}
+}
+
+// GetSongDetails получает информацию о песне из внешнего API
+func (p *InfoAPIProvider) GetSongDetails(ctx context.Context, group, songTitle string) (models.SongDetails, error) {
+	attempts := max(p.retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		if err := p.breaker.Allow(); err != nil {
+			return models.SongDetails{}, err
+		}
+
+		details, err := p.fetch(ctx, group, songTitle)
+		var retryErr *retryableError
+		switch {
+		case ctx.Err() != nil:
+			p.breaker.Abort()
+			return models.SongDetails{}, err
+		case !errors.As(err, &retryErr):
+			// Внешний API ответил, даже если ответ неуспешный
+			p.breaker.Success()
+			return details, err
+		}
+
+		p.breaker.Failure()
+		if attempt >= attempts {
+			return models.SongDetails{}, fmt.Errorf("попытки исчерпаны (%d): %w", attempts, err)
+		}
+
+		delay := max(p.backoff(attempt), retryErr.retryAfter)
+		if retryErr.retryAfter > 0 && p.retry.MaxRetryAfter > 0 && retryErr.retryAfter > p.retry.MaxRetryAfter {
+			return models.SongDetails{}, fmt.Errorf("внешний API просит повторить запрос через %s: %w", retryErr.retryAfter, err)
+		}
+
+		log.Printf("Попытка %d запроса к API не удалась: %v, повтор через %s", attempt, err, delay)
+		if err := p.sleep(ctx, delay); err != nil {
+			return models.SongDetails{}, err
+		}
+	}
+}
+
+// BreakerStatus возвращает состояние размыкателя внешнего API
+func (p *InfoAPIProvider) BreakerStatus() BreakerStatus {
+	return p.breaker.Status()
+}
+
+// fetch выполняет одну попытку запроса к внешнему API
+func (p *InfoAPIProvider) fetch(ctx context.Context, group, songTitle string) (models.SongDetails, error) {
+	baseURL, err := url.Parse(p.baseURL)
+	if err != nil {
+		log.Printf("Неверный адрес внешнего API: %v", err)
+		return models.SongDetails{}, fmt.Errorf("неверный адрес внешнего API: %w", err)
+	}
+	apiURL := baseURL.JoinPath("info")
+	query := apiURL.Query()
+	query.Set("group", group)
+	query.Set("song", songTitle)
+	apiURL.RawQuery = query.Encode()
+	log.Printf("Запрос к внешнему API: %s", RedactURL(apiURL))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL.String(), nil)
+	if err != nil {
+		log.Printf("Ошибка создания запроса к API: %v", err)
+		return models.SongDetails{}, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		log.Printf("Ошибка выполнения запроса к API: %v", err)
+		return models.SongDetails{}, &retryableError{err: fmt.Errorf("ошибка выполнения запроса: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return models.SongDetails{}, fmt.Errorf("песня %s - %s во внешнем API: %w", group, songTitle, models.ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Неуспешный статус код от API: %d", resp.StatusCode)
+		err := fmt.Errorf("неуспешный статус код: %d", resp.StatusCode)
+		if isRetryableStatus(resp.StatusCode) {
+			return models.SongDetails{}, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		}
+		return models.SongDetails{}, err
+	}
+
+	var body io.Reader = resp.Body
+	if p.maxBytes > 0 {
+		body = io.LimitReader(resp.Body, p.maxBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		log.Printf("Ошибка чтения ответа API: %v", err)
+		return models.SongDetails{}, &retryableError{err: fmt.Errorf("ошибка чтения ответа: %w", err)}
+	}
+	if p.maxBytes > 0 && int64(len(data)) > p.maxBytes {
+		log.Printf("Ответ API больше %d байт", p.maxBytes)
+		return models.SongDetails{}, fmt.Errorf("ответ внешнего API больше %d байт", p.maxBytes)
+	}
+
+	var details models.SongDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		log.Printf("Ошибка декодирования JSON от API: %v", err)
+		return models.SongDetails{}, fmt.Errorf("ошибка декодирования JSON: %w", err)
+	}
+
+	return details, nil
+}
+
+// backoff возвращает задержку перед повторной попыткой: экспоненциальная задержка,
+// половина которой выбирается случайно, чтобы клиенты не повторяли запросы одновременно
+func (p *InfoAPIProvider) backoff(attempt int) time.Duration {
+	if p.retry.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.retry.MaxDelay > 0 && delay > p.retry.MaxDelay) {
+		delay = p.retry.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// isRetryableStatus проверяет, что статус ответа говорит о временной недоступности API
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: количество секунд или дату HTTP
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// sleep ожидает указанное время или отмену контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// songJSON - ответ внешнего API с информацией о песне
+const songJSON = `{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com"}`
+
+// newTestProvider создает InfoAPIProvider для тестового сервера. Задержки между попытками
+// не выполняются, а записываются в delays.
+func newTestProvider(server *httptest.Server, client *http.Client, retry RetryConfig, breaker BreakerConfig) (*InfoAPIProvider, *[]time.Duration) {
+	if client == nil {
+		client = server.Client()
+	}
+	p := NewInfoAPIProvider(server.URL, client, 0, retry, breaker)
+	delays := &[]time.Duration{}
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+	return p, delays
+}
+
+func TestGetSongDetailsRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "временно недоступен", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, songJSON)
+	}))
+	defer server.Close()
+
+	p, delays := newTestProvider(server, nil, RetryConfig{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond}, BreakerConfig{})
+	details, err := p.GetSongDetails(context.Background(), "Muse", "Supermassive Black Hole")
+	if err != nil {
+		t.Fatalf("ожидался успешный ответ после повторов, получена ошибка: %v", err)
+	}
+	if details.ReleaseDate != "16.07.2006" {
+		t.Errorf("дата релиза %q, ожидалась 16.07.2006", details.ReleaseDate)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("выполнено %d запросов, ожидалось 3", got)
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("выполнено %d ожиданий, ожидалось 2", len(*delays))
+	}
+	// Вторая задержка в два раза больше первой с точностью до случайной половины
+	if (*delays)[0] < 50*time.Millisecond || (*delays)[0] > 100*time.Millisecond {
+		t.Errorf("первая задержка %s вне [50ms, 100ms]", (*delays)[0])
+	}
+	if (*delays)[1] < 100*time.Millisecond || (*delays)[1] > 200*time.Millisecond {
+		t.Errorf("вторая задержка %s вне [100ms, 200ms]", (*delays)[1])
+	}
+}
+
+func TestGetSongDetailsRetriesTimeouts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Первый ответ не приходит до истечения таймаута клиента
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, songJSON)
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+	p, _ := newTestProvider(server, client, RetryConfig{MaxAttempts: 2}, BreakerConfig{})
+	if _, err := p.GetSongDetails(context.Background(), "Muse", "Hysteria"); err != nil {
+		t.Fatalf("ожидался успешный ответ после таймаута, получена ошибка: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("выполнено %d запросов, ожидалось 2", got)
+	}
+}
+
+func TestGetSongDetailsGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "ошибка сервера", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p, _ := newTestProvider(server, nil, RetryConfig{MaxAttempts: 3}, BreakerConfig{})
+	if _, err := p.GetSongDetails(context.Background(), "Muse", "Uprising"); err == nil {
+		t.Fatal("ожидалась ошибка после исчерпания попыток")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("выполнено %d запросов, ожидалось 3", got)
+	}
+}
+
+func TestGetSongDetailsDoesNotRetryClientErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		notFound bool
+	}{
+		{name: "bad request", status: http.StatusBadRequest},
+		{name: "unauthorized", status: http.StatusUnauthorized},
+		{name: "not found", status: http.StatusNotFound, notFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p, delays := newTestProvider(server, nil, RetryConfig{MaxAttempts: 3}, BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+			_, err := p.GetSongDetails(context.Background(), "Muse", "Madness")
+			if err == nil {
+				t.Fatal("ожидалась ошибка")
+			}
+			if tt.notFound != errors.Is(err, models.ErrNotFound) {
+				t.Errorf("ошибка %v, ErrNotFound ожидался: %v", err, tt.notFound)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("выполнено %d запросов, ожидался 1", got)
+			}
+			if len(*delays) != 0 {
+				t.Errorf("выполнено %d ожиданий, повторов быть не должно", len(*delays))
+			}
+			// Ответ 4xx означает, что API доступен, поэтому размыкатель остается замкнутым
+			if state := p.BreakerStatus().State; state != BreakerClosed {
+				t.Errorf("состояние размыкателя %q, ожидалось %q", state, BreakerClosed)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerOpensAndHalfOpens(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "ошибка сервера", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, songJSON)
+	}))
+	defer server.Close()
+
+	p, _ := newTestProvider(server, nil, RetryConfig{MaxAttempts: 1}, BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	now := time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC)
+	p.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Две ошибки подряд размыкают размыкатель
+	for i := 0; i < 2; i++ {
+		if _, err := p.GetSongDetails(ctx, "Muse", "Starlight"); err == nil {
+			t.Fatalf("запрос %d: ожидалась ошибка сервера", i+1)
+		}
+	}
+	if state := p.BreakerStatus().State; state != BreakerOpen {
+		t.Fatalf("состояние размыкателя %q, ожидалось %q", state, BreakerOpen)
+	}
+
+	// Пока размыкатель разомкнут, запросы отклоняются без обращения к API
+	if _, err := p.GetSongDetails(ctx, "Muse", "Starlight"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("ожидалась ошибка ErrCircuitOpen, получена %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("выполнено %d запросов, ожидалось 2", got)
+	}
+
+	// После OpenTimeout пробный запрос проходит, его ошибка снова размыкает размыкатель
+	now = now.Add(time.Minute)
+	if _, err := p.GetSongDetails(ctx, "Muse", "Starlight"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("ожидалась ошибка пробного запроса, получена %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("выполнено %d запросов, ожидалось 3", got)
+	}
+	if state := p.BreakerStatus().State; state != BreakerOpen {
+		t.Fatalf("после неудачного пробного запроса состояние %q, ожидалось %q", state, BreakerOpen)
+	}
+
+	// Успешный пробный запрос замыкает размыкатель
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	if _, err := p.GetSongDetails(ctx, "Muse", "Starlight"); err != nil {
+		t.Fatalf("ожидался успешный пробный запрос, получена ошибка: %v", err)
+	}
+	status := p.BreakerStatus()
+	if status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("состояние размыкателя %+v, ожидалось замкнутое без ошибок", status)
+	}
+}
+
+func TestCircuitBreakerAllowsSingleProbeWhenHalfOpen(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	now := time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("пробный запрос отклонен: %v", err)
+	}
+	if state := b.Status().State; state != BreakerHalfOpen {
+		t.Fatalf("состояние размыкателя %q, ожидалось %q", state, BreakerHalfOpen)
+	}
+	// Пока пробный запрос выполняется, остальные запросы отклоняются
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("второй запрос в полуоткрытом состоянии не отклонен: %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+package provider
+
+import (
+	"context"
+	"music_library/internal/models"
+)
+
+// NoopProvider не обращается ни к каким источникам и возвращает пустую информацию.
+// Песни сохраняются только с данными, переданными пользователем.
+type NoopProvider struct{}
+
+// GetSongDetails возвращает пустую информацию о песне
+func (NoopProvider) GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error) {
+	return models.SongDetails{}, nil
+}
@@ -0,0 +1,222 @@
+// Package provider содержит источники дополнительной информации о песнях.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/models"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Виды источников информации о песнях
+const (
+	KindInfoAPI = "info"
+	KindFile    = "file"
+	KindNoop    = "none"
+	KindChain   = "chain"
+)
+
+// DetailsProvider - интерфейс источника информации о песне: даты релиза, текста и ссылки
+type DetailsProvider interface {
+	// GetSongDetails получает информацию о песне. Если песня неизвестна источнику,
+	// возвращается ошибка, совместимая с models.ErrNotFound.
+	GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error)
+}
+
+// ProviderStatus описывает состояние источника информации для диагностики
+type ProviderStatus struct {
+	Name    string         `json:"name"`
+	Breaker *BreakerStatus `json:"breaker,omitempty"`
+}
+
+// StatusReporter - источник, который сообщает состояние своих подключений для диагностики
+type StatusReporter interface {
+	Status() []ProviderStatus
+}
+
+// Config описывает выбор и настройки источника информации о песнях
+type Config struct {
+	// Kind - вид источника: info, file, none или chain. По умолчанию info.
+	Kind string
+	// APIURL - адрес внешнего API для источника info
+	APIURL string
+	// FilePath - путь к JSON- или CSV-файлу для источника file
+	FilePath string
+	// Chain - описание цепочки источников для вида chain, см. ParseChain
+	Chain string
+	// Precedence - порядок источников для отдельных полей цепочки, см. ParsePrecedence
+	Precedence string
+	// Retry - повторные попытки запросов к внешнему API
+	Retry RetryConfig
+	// Breaker - размыкатель запросов к внешнему API
+	Breaker BreakerConfig
+	// Cache - кэш ответов источника
+	Cache CacheConfig
+	// HTTP - общий HTTP-клиент для запросов к внешним API
+	HTTP HTTPConfig
+}
+
+// ConfigFromEnv читает конфигурацию источника информации о песнях из переменных окружения.
+// Для незаданных настроек повторов и размыкателя используются значения по умолчанию.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Kind:       os.Getenv("DETAILS_PROVIDER"),
+		APIURL:     os.Getenv("API_URL"),
+		FilePath:   os.Getenv("DETAILS_FILE"),
+		Chain:      os.Getenv("DETAILS_CHAIN"),
+		Precedence: os.Getenv("DETAILS_PRECEDENCE"),
+		Retry: RetryConfig{
+			MaxAttempts:   3,
+			BaseDelay:     200 * time.Millisecond,
+			MaxDelay:      5 * time.Second,
+			MaxRetryAfter: 30 * time.Second,
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+		},
+		Cache: CacheConfig{
+			Kind:            os.Getenv("DETAILS_CACHE"),
+			TTL:             24 * time.Hour,
+			NegativeTTL:     time.Hour,
+			Size:            10000,
+			CleanupInterval: time.Hour,
+		},
+		HTTP: HTTPConfig{
+			Timeout:               10 * time.Second,
+			DialTimeout:           5 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			CAFile:                os.Getenv("HTTP_CLIENT_CA_FILE"),
+			UserAgent:             "music_library/1.0",
+			MaxResponseBytes:      1 << 20,
+		},
+	}
+	if value := os.Getenv("HTTP_CLIENT_USER_AGENT"); value != "" {
+		cfg.HTTP.UserAgent = value
+	}
+	if value := os.Getenv("HTTP_CLIENT_MAX_RESPONSE_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("неверное значение HTTP_CLIENT_MAX_RESPONSE_BYTES: %w", err)
+		}
+		cfg.HTTP.MaxResponseBytes = n
+	}
+	if value := os.Getenv("HTTP_CLIENT_DEBUG"); value != "" {
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("неверное значение HTTP_CLIENT_DEBUG: %w", err)
+		}
+		cfg.HTTP.Debug = debug
+	}
+
+	switch cfg.Cache.Kind {
+	case "":
+		cfg.Cache.Kind = CacheNone
+	case CacheNone, CacheMemory, CachePostgres:
+	default:
+		return Config{}, fmt.Errorf("неизвестный вид кэша информации о песнях: %q", cfg.Cache.Kind)
+	}
+
+	ints := map[string]*int{
+		"INFO_API_MAX_ATTEMPTS":               &cfg.Retry.MaxAttempts,
+		"INFO_API_BREAKER_THRESHOLD":          &cfg.Breaker.FailureThreshold,
+		"DETAILS_CACHE_SIZE":                  &cfg.Cache.Size,
+		"HTTP_CLIENT_MAX_IDLE_CONNS":          &cfg.HTTP.MaxIdleConns,
+		"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST": &cfg.HTTP.MaxIdleConnsPerHost,
+	}
+	for name, dst := range ints {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("неверное значение %s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"INFO_API_BASE_DELAY":                 &cfg.Retry.BaseDelay,
+		"INFO_API_MAX_DELAY":                  &cfg.Retry.MaxDelay,
+		"INFO_API_MAX_RETRY_AFTER":            &cfg.Retry.MaxRetryAfter,
+		"INFO_API_BREAKER_TIMEOUT":            &cfg.Breaker.OpenTimeout,
+		"DETAILS_CACHE_TTL":                   &cfg.Cache.TTL,
+		"DETAILS_CACHE_NEGATIVE_TTL":          &cfg.Cache.NegativeTTL,
+		"DETAILS_CACHE_CLEANUP_INTERVAL":      &cfg.Cache.CleanupInterval,
+		"HTTP_CLIENT_TIMEOUT":                 &cfg.HTTP.Timeout,
+		"HTTP_CLIENT_DIAL_TIMEOUT":            &cfg.HTTP.DialTimeout,
+		"HTTP_CLIENT_TLS_TIMEOUT":             &cfg.HTTP.TLSHandshakeTimeout,
+		"HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT": &cfg.HTTP.ResponseHeaderTimeout,
+		"HTTP_CLIENT_IDLE_CONN_TIMEOUT":       &cfg.HTTP.IdleConnTimeout,
+	}
+	for name, dst := range durations {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("неверное значение %s: %w", name, err)
+			}
+			*dst = d
+		}
+	}
+	if cfg.Cache.CleanupInterval <= 0 {
+		return Config{}, fmt.Errorf("неверное значение DETAILS_CACHE_CLEANUP_INTERVAL: %s", cfg.Cache.CleanupInterval)
+	}
+
+	return cfg, nil
+}
+
+// New создает источник информации о песнях по конфигурации.
+// Источник всегда заполняет происхождение полей: для одиночного источника это его вид,
+// для цепочки - имя источника, предоставившего поле.
+func New(cfg Config) (DetailsProvider, error) {
+	client, err := NewHTTPClient(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Kind != KindChain {
+		kind := cfg.Kind
+		if kind == "" {
+			kind = KindInfoAPI
+		}
+		p, err := newSingle(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		return NewChain([]NamedProvider{{Name: kind, Provider: p}}, nil)
+	}
+
+	providers, err := ParseChain(cfg.Chain, cfg, client)
+	if err != nil {
+		return nil, err
+	}
+	precedence, err := ParsePrecedence(cfg.Precedence)
+	if err != nil {
+		return nil, err
+	}
+	return NewChain(providers, precedence)
+}
+
+// newSingle создает одиночный источник информации о песнях.
+// Источники внешних API используют общий HTTP-клиент client.
+func newSingle(cfg Config, client *http.Client) (DetailsProvider, error) {
+	switch cfg.Kind {
+	case "", KindInfoAPI:
+		if cfg.APIURL == "" {
+			return nil, fmt.Errorf("не задан адрес внешнего API")
+		}
+		return NewInfoAPIProvider(cfg.APIURL, client, cfg.HTTP.MaxResponseBytes, cfg.Retry, cfg.Breaker), nil
+	case KindFile:
+		return NewFileProvider(cfg.FilePath)
+	case KindNoop:
+		return NoopProvider{}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный источник информации о песнях: %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"strings"
+)
+
+// AnnotationServiceImpl реализует интерфейс AnnotationService
+type AnnotationServiceImpl struct {
+	db database.AnnotationDB
+}
+
+// NewAnnotationService создает новый AnnotationServiceImpl
+func NewAnnotationService(db database.AnnotationDB) *AnnotationServiceImpl {
+	return &AnnotationServiceImpl{db: db}
+}
+
+// GetAnnotations получает аннотации песни, ответы вложены в replies аннотаций, на которые они отвечают
+func (s *AnnotationServiceImpl) GetAnnotations(ctx context.Context, songID int) ([]models.Annotation, error) {
+	annotations, err := s.db.GetAnnotations(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	return annotationTree(annotations), nil
+}
+
+// CreateAnnotation проверяет текст и добавляет аннотацию к диапазону символов куплета
+func (s *AnnotationServiceImpl) CreateAnnotation(ctx context.Context, songID int, req models.AnnotationRequest) (models.Annotation, error) {
+	if err := validateAnnotationBody(req.Body); err != nil {
+		return models.Annotation{}, err
+	}
+	return s.db.CreateAnnotation(ctx, songID, req)
+}
+
+// CreateReply проверяет текст и добавляет ответ на аннотацию
+func (s *AnnotationServiceImpl) CreateReply(ctx context.Context, songID, parentID int, req models.ReplyRequest) (models.Annotation, error) {
+	if err := validateAnnotationBody(req.Body); err != nil {
+		return models.Annotation{}, err
+	}
+	return s.db.CreateReply(ctx, songID, parentID, req.Body)
+}
+
+// UpdateAnnotation проверяет текст и изменяет аннотацию
+func (s *AnnotationServiceImpl) UpdateAnnotation(ctx context.Context, songID, id int, upd models.AnnotationUpdate) (models.Annotation, error) {
+	if err := validateAnnotationBody(upd.Body); err != nil {
+		return models.Annotation{}, err
+	}
+	return s.db.UpdateAnnotation(ctx, songID, id, upd)
+}
+
+// DeleteAnnotation удаляет аннотацию вместе с ответами
+func (s *AnnotationServiceImpl) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	return s.db.DeleteAnnotation(ctx, songID, id)
+}
+
+// validateAnnotationBody проверяет, что текст аннотации или ответа не пустой
+func validateAnnotationBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: текст аннотации не может быть пустым", models.ErrInvalidInput)
+	}
+	return nil
+}
+
+// annotationTree собирает деревья ответов из списка аннотаций, упорядоченного по ID.
+// Ответ всегда добавляется позже аннотации, на которую отвечает, поэтому родитель встречается раньше.
+func annotationTree(annotations []models.Annotation) []models.Annotation {
+	children := make(map[int][]models.Annotation)
+	var roots []models.Annotation
+	for _, annotation := range annotations {
+		if annotation.ParentID == nil {
+			roots = append(roots, annotation)
+			continue
+		}
+		children[*annotation.ParentID] = append(children[*annotation.ParentID], annotation)
+	}
+
+	var attach func(annotation models.Annotation) models.Annotation
+	attach = func(annotation models.Annotation) models.Annotation {
+		for _, reply := range children[annotation.ID] {
+			annotation.Replies = append(annotation.Replies, attach(reply))
+		}
+		return annotation
+	}
+
+	tree := make([]models.Annotation, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, attach(root))
+	}
+	return tree
+}
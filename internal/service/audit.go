@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/database"
+	"music_library/internal/models"
+)
+
+// auditEntities - сущности, изменения которых записываются в журнал аудита
+var auditEntities = map[string]bool{
+	models.AuditEntitySong:      true,
+	models.AuditEntityVerse:     true,
+	models.AuditEntityPlaylist:  true,
+	models.AuditEntitySmartList: true,
+}
+
+// AuditServiceImpl реализует интерфейс AuditService
+type AuditServiceImpl struct {
+	db database.AuditDB
+}
+
+// NewAuditService создает новый AuditServiceImpl
+func NewAuditService(db database.AuditDB) *AuditServiceImpl {
+	return &AuditServiceImpl{db: db}
+}
+
+// GetAuditLog проверяет условия отбора и получает записи журнала аудита, новые первыми
+func (s *AuditServiceImpl) GetAuditLog(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	if filter.Entity != "" && !auditEntities[filter.Entity] {
+		return nil, fmt.Errorf("%w: неизвестная сущность %q, ожидается song, verse, playlist или smart_list", models.ErrInvalidInput, filter.Entity)
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, fmt.Errorf("%w: начало периода позже его конца", models.ErrInvalidInput)
+	}
+	return s.db.GetAuditLog(ctx, filter, limit, offset)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/auth"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"strings"
+	"time"
+)
+
+// AuthServiceImpl реализует интерфейс AuthService
+type AuthServiceImpl struct {
+	db     database.UserDB
+	tokens *auth.Tokens
+}
+
+// NewAuthService создает новый AuthServiceImpl. tokens может быть nil, если сервис только
+// управляет пользователями и ключами API (команда useradd): вход и проверка токенов тогда недоступны.
+func NewAuthService(db database.UserDB, tokens *auth.Tokens) *AuthServiceImpl {
+	return &AuthServiceImpl{db: db, tokens: tokens}
+}
+
+// CreateUser проверяет имя, пароль и роль и регистрирует пользователя.
+// Без роли пользователь получает роль viewer.
+func (s *AuthServiceImpl) CreateUser(ctx context.Context, req models.UserRequest) (int, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return 0, fmt.Errorf("%w: не указано имя пользователя", models.ErrInvalidInput)
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		return 0, fmt.Errorf("%w: пароль короче %d символов", models.ErrInvalidInput, auth.MinPasswordLength)
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if err := models.ValidateRole(req.Role); err != nil {
+		return 0, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return 0, err
+	}
+	return s.db.CreateUser(ctx, models.User{Username: username, PasswordHash: hash, Role: req.Role})
+}
+
+// GetUsers получает всех пользователей
+func (s *AuthServiceImpl) GetUsers(ctx context.Context) ([]models.User, error) {
+	return s.db.GetUsers(ctx)
+}
+
+// SetUserRole проверяет роль и изменяет ее. Выданные токены доступа сразу
+// начинают действовать с новой ролью, так как пользователь читается при каждом запросе.
+func (s *AuthServiceImpl) SetUserRole(ctx context.Context, id int, role string) error {
+	if err := models.ValidateRole(role); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+	return s.db.SetUserRole(ctx, id, role)
+}
+
+// DeleteUser удаляет пользователя, его токены доступа перестают действовать сразу
+func (s *AuthServiceImpl) DeleteUser(ctx context.Context, id int) error {
+	return s.db.DeleteUser(ctx, id)
+}
+
+// Login проверяет имя и пароль и выдает токен доступа и токен обновления.
+// Неизвестное имя и неверный пароль неразличимы для клиента.
+func (s *AuthServiceImpl) Login(ctx context.Context, username, password string) (models.TokenPair, error) {
+	user, err := s.db.GetUserByUsername(ctx, strings.TrimSpace(username))
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return models.TokenPair{}, err
+	}
+	if !auth.CheckPassword(user.PasswordHash, password) {
+		log.Printf("Неудачная попытка входа пользователя %q", username)
+		return models.TokenPair{}, fmt.Errorf("%w: неверное имя пользователя или пароль", models.ErrUnauthorized)
+	}
+
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if err := s.db.CreateRefreshToken(ctx, user.ID, auth.HashToken(refreshToken), s.tokens.RefreshExpiry()); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return s.issue(user, refreshToken)
+}
+
+// Refresh заменяет токен обновления новым и выдает новый токен доступа
+func (s *AuthServiceImpl) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	if refreshToken == "" {
+		return models.TokenPair{}, fmt.Errorf("%w: не указан токен обновления", models.ErrUnauthorized)
+	}
+
+	next, err := auth.NewRefreshToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	user, err := s.db.RotateRefreshToken(ctx, auth.HashToken(refreshToken), auth.HashToken(next), s.tokens.RefreshExpiry())
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return s.issue(user, next)
+}
+
+// Logout отзывает токен обновления. Выданный токен доступа действует до истечения срока.
+func (s *AuthServiceImpl) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return fmt.Errorf("%w: не указан токен обновления", models.ErrInvalidInput)
+	}
+	return s.db.RevokeRefreshToken(ctx, auth.HashToken(refreshToken))
+}
+
+// Authenticate проверяет токен доступа и получает пользователя из базы данных,
+// чтобы токен удаленного пользователя перестал действовать сразу
+func (s *AuthServiceImpl) Authenticate(ctx context.Context, accessToken string) (models.User, error) {
+	id, err := s.tokens.ParseAccess(accessToken)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := s.db.GetUserByID(ctx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return models.User{}, fmt.Errorf("%w: пользователь %d не существует", models.ErrUnauthorized, id)
+	}
+	return user, err
+}
+
+// issue выдает токен доступа и собирает ответ с токенами
+func (s *AuthServiceImpl) issue(user models.User, refreshToken string) (models.TokenPair, error) {
+	accessToken, expiresAt, err := s.tokens.IssueAccess(user)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// CreateAPIKey проверяет запрос и создает ключ API. Хранится только хэш ключа,
+// поэтому открытый ключ нельзя получить повторно. Создатель берется из контекста.
+func (s *AuthServiceImpl) CreateAPIKey(ctx context.Context, req models.APIKeyRequest) (models.CreatedAPIKey, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := req.Validate(); err != nil {
+		return models.CreatedAPIKey{}, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+
+	apiKey := models.APIKey{Name: req.Name, Prefix: prefix, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		apiKey.CreatedBy = &user.ID
+	}
+
+	apiKey, err = s.db.CreateAPIKey(ctx, apiKey, auth.HashToken(key))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// GetAPIKeys получает все ключи API
+func (s *AuthServiceImpl) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.db.GetAPIKeys(ctx)
+}
+
+// RevokeAPIKey отзывает ключ API
+func (s *AuthServiceImpl) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.db.RevokeAPIKey(ctx, id)
+}
+
+// AuthenticateAPIKey проверяет ключ API и возвращает его описание
+func (s *AuthServiceImpl) AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error) {
+	return s.db.UseAPIKey(ctx, auth.HashToken(key))
+}
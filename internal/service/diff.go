@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/models"
+	"music_library/internal/textdiff"
+	"sort"
+	"strings"
+)
+
+// unifiedContext - количество строк контекста в unified diff
+const unifiedContext = 3
+
+// DiffRevisions возвращает построчные отличия куплетов песни между двумя ревизиями.
+// Если to равен нулю, сравнение выполняется с последней ревизией.
+func (s *MusicServiceImpl) DiffRevisions(ctx context.Context, songID, from, to int) (models.LyricsDiff, error) {
+	if to == 0 {
+		latest, err := s.db.GetRevisions(ctx, songID, 1, 0)
+		if err != nil {
+			return models.LyricsDiff{}, err
+		}
+		if len(latest) == 0 {
+			return models.LyricsDiff{}, fmt.Errorf("ревизии песни %d: %w", songID, models.ErrNotFound)
+		}
+		to = latest[0].Revision
+	}
+
+	oldRev, err := s.db.GetRevision(ctx, songID, from)
+	if err != nil {
+		return models.LyricsDiff{}, err
+	}
+	newRev, err := s.db.GetRevision(ctx, songID, to)
+	if err != nil {
+		return models.LyricsDiff{}, err
+	}
+
+	return diffLyrics(songID, oldRev, newRev), nil
+}
+
+// diffLyrics сравнивает куплеты двух ревизий по номерам куплетов
+func diffLyrics(songID int, oldRev, newRev models.SongRevision) models.LyricsDiff {
+	oldVerses := verseTexts(oldRev.Snapshot.Verses)
+	newVerses := verseTexts(newRev.Snapshot.Verses)
+
+	numbers := make([]int, 0, len(oldVerses)+len(newVerses))
+	for n := range oldVerses {
+		numbers = append(numbers, n)
+	}
+	for n := range newVerses {
+		if _, ok := oldVerses[n]; !ok {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+
+	result := models.LyricsDiff{
+		SongID: songID,
+		From:   oldRev.Revision,
+		To:     newRev.Revision,
+		Verses: []models.VerseDiff{},
+	}
+
+	var unified strings.Builder
+	for _, n := range numbers {
+		a := textdiff.SplitLines(oldVerses[n])
+		b := textdiff.SplitLines(newVerses[n])
+
+		lines := lineChanges(textdiff.Lines(a, b))
+		if len(lines) == 0 {
+			continue
+		}
+		result.Verses = append(result.Verses, models.VerseDiff{VerseNumber: n, Lines: lines})
+
+		unified.WriteString(textdiff.Unified(
+			fmt.Sprintf("a/revision-%d/verse-%d", oldRev.Revision, n),
+			fmt.Sprintf("b/revision-%d/verse-%d", newRev.Revision, n),
+			a, b, unifiedContext,
+		))
+	}
+	result.Unified = unified.String()
+
+	return result
+}
+
+// lineChanges преобразует операции сравнения в список изменений строк.
+// Подряд идущие удаления и вставки попарно объединяются в измененные строки.
+func lineChanges(ops []textdiff.Op) []models.LineChange {
+	changes := []models.LineChange{}
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == textdiff.Equal {
+			i++
+			continue
+		}
+
+		var deleted, inserted []textdiff.Op
+		for ; i < len(ops) && ops[i].Kind != textdiff.Equal; i++ {
+			if ops[i].Kind == textdiff.Delete {
+				deleted = append(deleted, ops[i])
+			} else {
+				inserted = append(inserted, ops[i])
+			}
+		}
+
+		paired := min(len(deleted), len(inserted))
+		for k := 0; k < paired; k++ {
+			changes = append(changes, models.LineChange{
+				Type:    models.LineChanged,
+				OldLine: deleted[k].OldIndex + 1,
+				NewLine: inserted[k].NewIndex + 1,
+				Old:     deleted[k].Text,
+				New:     inserted[k].Text,
+			})
+		}
+		for _, op := range deleted[paired:] {
+			changes = append(changes, models.LineChange{Type: models.LineRemoved, OldLine: op.OldIndex + 1, Old: op.Text})
+		}
+		for _, op := range inserted[paired:] {
+			changes = append(changes, models.LineChange{Type: models.LineAdded, NewLine: op.NewIndex + 1, New: op.Text})
+		}
+	}
+	return changes
+}
+
+// verseTexts возвращает тексты куплетов по их номерам
+func verseTexts(verses []models.Verse) map[int]string {
+	texts := make(map[int]string, len(verses))
+	for _, verse := range verses {
+		texts[verse.VerseNumber] = verse.Text
+	}
+	return texts
+}
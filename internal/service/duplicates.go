@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+	"strings"
+)
+
+// lyricsSimilarityThreshold - минимальное сходство текстов, при котором песня
+// той же группы под другим названием считается дубликатом
+const lyricsSimilarityThreshold = 0.8
+
+// findDuplicate ищет в библиотеке песню, совпадающую с создаваемой.
+// Песня считается дубликатом, если у нее то же нормализованное название
+// либо почти тот же текст при совпадающей группе.
+func (s *MusicServiceImpl) findDuplicate(ctx context.Context, song models.Song, text string) error {
+	candidates, err := s.db.FindSongsByGroupKey(ctx, normalize.Key(song.Group))
+	if err != nil {
+		return fmt.Errorf("ошибка поиска дубликатов: %w", err)
+	}
+
+	songKey := normalize.Key(song.Song)
+	for _, candidate := range candidates {
+		if normalize.Key(candidate.Song) == songKey {
+			return &models.DuplicateSongError{Existing: candidate, Similarity: 1}
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	for _, candidate := range candidates {
+		verses, err := s.db.GetVersesBySongID(ctx, candidate.ID, maxVersesPerSong, 0)
+		if err != nil {
+			return fmt.Errorf("ошибка поиска дубликатов: %w", err)
+		}
+		texts := make([]string, len(verses))
+		for i, verse := range verses {
+			texts[i] = verse.Text
+		}
+
+		similarity := lyricsSimilarity(text, strings.Join(texts, "\n\n"))
+		if similarity >= lyricsSimilarityThreshold {
+			return &models.DuplicateSongError{Existing: candidate, Similarity: similarity}
+		}
+	}
+
+	return nil
+}
+
+// lyricsSimilarity возвращает коэффициент Жаккара для множеств пар соседних слов двух текстов
+func lyricsSimilarity(a, b string) float64 {
+	setA, setB := wordPairs(a), wordPairs(b)
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	common := 0
+	for pair := range setA {
+		if _, ok := setB[pair]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// wordPairs возвращает множество пар соседних нормализованных слов текста
+func wordPairs(text string) map[string]struct{} {
+	words := normalize.Words(text)
+	pairs := make(map[string]struct{}, len(words))
+	if len(words) == 1 {
+		pairs[words[0]] = struct{}{}
+	}
+	for i := 1; i < len(words); i++ {
+		pairs[words[i-1]+" "+words[i]] = struct{}{}
+	}
+	return pairs
+}
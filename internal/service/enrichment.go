@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"music_library/internal/provider"
+	"os"
+	"strconv"
+	"time"
+)
+
+// enrichmentLease - время, на которое выбранная песня откладывается, чтобы ее не взял
+// другой экземпляр сервиса. Если обработчик завершится аварийно, песня будет обработана повторно.
+const enrichmentLease = 5 * time.Minute
+
+// EnrichmentConfig описывает настройки фонового получения информации о песнях
+type EnrichmentConfig struct {
+	// Interval - период проверки песен, ожидающих получения информации
+	Interval time.Duration
+	// BatchSize - количество песен, обрабатываемых за одну проверку
+	BatchSize int
+	// MaxAttempts - количество неудачных попыток, после которого песня получает статус failed
+	MaxAttempts int
+	// RetryDelay - задержка перед первой повторной попыткой, далее удваивается
+	RetryDelay time.Duration
+	// MaxRetryDelay - максимальная задержка между попытками
+	MaxRetryDelay time.Duration
+}
+
+// EnrichmentConfigFromEnv читает настройки фонового получения информации из переменных окружения.
+// Для незаданных настроек используются значения по умолчанию.
+func EnrichmentConfigFromEnv() (EnrichmentConfig, error) {
+	cfg := EnrichmentConfig{
+		Interval:      30 * time.Second,
+		BatchSize:     20,
+		MaxAttempts:   10,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+	}
+
+	ints := map[string]*int{
+		"ENRICH_BATCH_SIZE":   &cfg.BatchSize,
+		"ENRICH_MAX_ATTEMPTS": &cfg.MaxAttempts,
+	}
+	for name, dst := range ints {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return EnrichmentConfig{}, fmt.Errorf("неверное значение %s: %q", name, value)
+			}
+			*dst = n
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"ENRICH_INTERVAL":        &cfg.Interval,
+		"ENRICH_RETRY_DELAY":     &cfg.RetryDelay,
+		"ENRICH_MAX_RETRY_DELAY": &cfg.MaxRetryDelay,
+	}
+	for name, dst := range durations {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return EnrichmentConfig{}, fmt.Errorf("неверное значение %s: %q", name, value)
+			}
+			*dst = d
+		}
+	}
+
+	return cfg, nil
+}
+
+// Enricher в фоне повторяет получение информации для песен, сохраненных без нее
+type Enricher struct {
+	db      database.SongDB
+	details provider.DetailsProvider
+	cfg     EnrichmentConfig
+}
+
+// NewEnricher создает новый Enricher
+func NewEnricher(db database.SongDB, details provider.DetailsProvider, cfg EnrichmentConfig) *Enricher {
+	return &Enricher{db: db, details: details, cfg: cfg}
+}
+
+// Run обрабатывает ожидающие песни с периодом cfg.Interval до отмены контекста
+func (e *Enricher) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.processBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch получает информацию для одной партии ожидающих песен
+func (e *Enricher) processBatch(ctx context.Context) {
+	tasks, err := e.db.ClaimEnrichmentTasks(ctx, e.cfg.BatchSize, enrichmentLease)
+	if err != nil {
+		log.Printf("Ошибка выбора песен для получения информации: %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		e.enrich(ctx, task)
+	}
+}
+
+// enrich запрашивает информацию о песне и сохраняет результат попытки
+func (e *Enricher) enrich(ctx context.Context, task models.EnrichmentTask) {
+	details, err := e.details.GetSongDetails(ctx, task.Group, task.Song)
+	if err == nil {
+		err = e.db.CompleteEnrichment(ctx, task.SongID, details, versesFromText(task.SongID, details.Text))
+		if err != nil {
+			log.Printf("Ошибка сохранения информации о песне %d: %v", task.SongID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	attempts := task.Attempts + 1
+	if !provider.IsNotFound(err) && attempts < e.cfg.MaxAttempts {
+		at := time.Now().Add(e.retryDelay(attempts))
+		retryAt = &at
+	}
+	log.Printf("Попытка %d получения информации о песне %d не удалась: %v", attempts, task.SongID, err)
+
+	if err := e.db.FailEnrichment(ctx, task.SongID, err.Error(), retryAt); err != nil {
+		log.Printf("Ошибка записи неудачной попытки для песни %d: %v", task.SongID, err)
+	}
+}
+
+// retryDelay возвращает экспоненциальную задержку перед следующей попыткой
+func (e *Enricher) retryDelay(attempts int) time.Duration {
+	delay := e.cfg.RetryDelay << (attempts - 1)
+	if delay <= 0 || delay > e.cfg.MaxRetryDelay {
+		return e.cfg.MaxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"strings"
+)
+
+const (
+	// importBatchSize - количество строк, сохраняемых в одной транзакции
+	importBatchSize = 500
+	// maxImportErrors - количество ошибок строк, включаемых в итог импорта
+	maxImportErrors = 1000
+)
+
+// ImportSongs читает строки импорта по одной и сохраняет их партиями.
+// Ошибки отдельных строк попадают в итог и не прерывают импорт.
+func (s *MusicServiceImpl) ImportSongs(ctx context.Context, rows importer.Reader, opts models.ImportOptions) (models.ImportReport, error) {
+	report := models.ImportReport{DryRun: opts.DryRun, Errors: []models.ImportRowResult{}, Conflicting: []models.ImportRowResult{}}
+	batch := make([]models.ImportRow, 0, importBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := s.db.ImportSongs(ctx, batch, opts)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			report.Add(result, maxImportErrors)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			report.Add(models.ImportRowResult{Line: rowErr.Line, Source: rowErr.Source,
+				Status: models.ImportFailed, Error: rowErr.Err.Error()}, maxImportErrors)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		row.Group, row.Song = strings.TrimSpace(row.Group), strings.TrimSpace(row.Song)
+		if row.Group == "" || row.Song == "" {
+			report.Add(models.ImportRowResult{Line: row.Line, Source: row.Source, Group: row.Group, Song: row.Song,
+				Status: models.ImportFailed, Error: "не заданы названия группы и песни"}, maxImportErrors)
+			continue
+		}
+		if row.Lyrics != "" {
+			row.Verses = versesFromText(0, row.Lyrics)
+		}
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+
+	log.Printf("Импорт завершен: строк %d, добавлено %d, обновлено %d, без изменений %d, пропущено %d, конфликтов %d, ошибок %d",
+		report.Total, report.Created, report.Updated, report.Unchanged, report.Skipped, report.Conflicts, report.Failed)
+	return report, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"music_library/internal/actor"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JobConfig описывает настройки пула обработчиков фоновых задач
+type JobConfig struct {
+	// Workers - количество задач, выполняемых одновременно
+	Workers int
+	// PollInterval - период проверки очереди, если о новых задачах не сообщалось
+	PollInterval time.Duration
+	// StaleAfter - время без отметки выполнения, после которого задача считается зависшей и возвращается
+	// в очередь. Обработчик обновляет отметку втрое чаще, очередь проверяется на зависшие задачи вдвое чаще.
+	StaleAfter time.Duration
+}
+
+// JobConfigFromEnv читает настройки пула обработчиков из переменных окружения.
+// Для незаданных настроек используются значения по умолчанию.
+func JobConfigFromEnv() (JobConfig, error) {
+	cfg := JobConfig{
+		Workers:      4,
+		PollInterval: 5 * time.Second,
+		StaleAfter:   10 * time.Minute,
+	}
+
+	if value := os.Getenv("JOB_WORKERS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return JobConfig{}, fmt.Errorf("неверное значение JOB_WORKERS: %q", value)
+		}
+		cfg.Workers = n
+	}
+
+	durations := map[string]*time.Duration{
+		"JOB_POLL_INTERVAL": &cfg.PollInterval,
+		"JOB_STALE_AFTER":   &cfg.StaleAfter,
+	}
+	for name, dst := range durations {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return JobConfig{}, fmt.Errorf("неверное значение %s: %q", name, value)
+			}
+			*dst = d
+		}
+	}
+
+	return cfg, nil
+}
+
+// JobServiceImpl реализует интерфейс JobService: хранит задачи в базе данных
+// и выполняет их пулом из cfg.Workers обработчиков
+type JobServiceImpl struct {
+	db    database.JobDB
+	music MusicService
+	cfg   JobConfig
+	wake  chan struct{}
+}
+
+// NewJobService создает новый JobServiceImpl
+func NewJobService(db database.JobDB, music MusicService, cfg JobConfig) *JobServiceImpl {
+	return &JobServiceImpl{db: db, music: music, cfg: cfg, wake: make(chan struct{}, 1)}
+}
+
+// EnqueueSong ставит в очередь задачу создания песни и возвращает ID задачи
+func (s *JobServiceImpl) EnqueueSong(ctx context.Context, song models.Song, force bool) (int, error) {
+	payload, err := json.Marshal(models.CreateSongPayload{Song: song, Force: force})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка кодирования задачи: %w", err)
+	}
+
+	id, err := s.db.CreateJob(ctx, models.JobKindCreateSong, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	// Свободный обработчик берет задачу сразу, не дожидаясь очередной проверки очереди
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// GetJob получает задачу по ID
+func (s *JobServiceImpl) GetJob(ctx context.Context, id int) (models.Job, error) {
+	return s.db.GetJob(ctx, id)
+}
+
+// Run запускает обработчики задач и периодический возврат зависших задач в очередь
+// и ждет их завершения после отмены контекста
+func (s *JobServiceImpl) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.requeueStale(ctx)
+	}()
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// requeueStale возвращает в очередь задачи остановившихся обработчиков, в том числе других
+// экземпляров сервиса: сразу при запуске и затем каждые StaleAfter/2
+func (s *JobServiceImpl) requeueStale(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.StaleAfter / 2)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.db.RequeueStaleJobs(ctx, s.cfg.StaleAfter); err != nil {
+			log.Printf("Ошибка возврата зависших задач в очередь: %v", err)
+		} else if n > 0 {
+			log.Printf("Возвращено в очередь зависших задач: %d", n)
+			select {
+			case s.wake <- struct{}{}:
+			default:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// heartbeat обновляет отметку выполнения задачи каждые StaleAfter/3, пока не отменен контекст
+func (s *JobServiceImpl) heartbeat(ctx context.Context, jobID int) {
+	ticker := time.NewTicker(s.cfg.StaleAfter / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.db.HeartbeatJob(ctx, jobID); err != nil && ctx.Err() == nil {
+				log.Printf("Ошибка обновления отметки выполнения задачи %d: %v", jobID, err)
+			}
+		}
+	}
+}
+
+// work выполняет задачи из очереди, пока она не опустеет, затем ждет новых задач
+func (s *JobServiceImpl) work(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := s.db.ClaimJob(ctx)
+		if err == nil {
+			s.process(ctx, job)
+			continue
+		}
+		if !errors.Is(err, models.ErrNotFound) {
+			log.Printf("Ошибка выбора задачи: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// process выполняет задачу и сохраняет ее результат. Пока задача выполняется,
+// обновляется ее отметка выполнения, чтобы задачу не вернули в очередь.
+func (s *JobServiceImpl) process(ctx context.Context, job models.Job) {
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go s.heartbeat(heartbeatCtx, job.ID)
+
+	var (
+		result any
+		err    error
+	)
+	switch job.Kind {
+	case models.JobKindCreateSong:
+		result, err = s.createSong(ctx, job)
+	default:
+		err = fmt.Errorf("неизвестный вид задачи: %q", job.Kind)
+	}
+
+	var resultJSON []byte
+	if result != nil {
+		var marshalErr error
+		if resultJSON, marshalErr = json.Marshal(result); marshalErr != nil {
+			err = errors.Join(err, fmt.Errorf("ошибка кодирования результата задачи: %w", marshalErr))
+		}
+	}
+
+	errMessage := ""
+	if err != nil {
+		errMessage = err.Error()
+		log.Printf("Задача %d завершилась ошибкой: %v", job.ID, err)
+	}
+	if err := s.db.FinishJob(ctx, job.ID, resultJSON, errMessage); err != nil {
+		log.Printf("Ошибка сохранения результата задачи %d: %v", job.ID, err)
+	}
+}
+
+// createSong выполняет задачу создания песни от имени пользователя и запроса, поставивших задачу.
+// Для найденного дубликата в результат записывается существующая песня.
+func (s *JobServiceImpl) createSong(ctx context.Context, job models.Job) (any, error) {
+	var payload models.CreateSongPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования задачи: %w", err)
+	}
+
+	ctx = actor.WithName(ctx, job.Actor)
+	ctx = actor.WithRequest(ctx, actor.Request{ID: job.RequestID, IP: job.IP})
+	id, err := s.music.AddSong(ctx, payload.Song, payload.Force)
+
+	var dupErr *models.DuplicateSongError
+	if errors.As(err, &dupErr) {
+		return map[string]any{"existing": dupErr.Existing, "similarity": dupErr.Similarity}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int{"id": id}, nil
+}
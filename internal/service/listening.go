@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"time"
+)
+
+// maxPlayClockSkew - насколько время прослушивания может опережать часы сервера
+const maxPlayClockSkew = 5 * time.Minute
+
+// ListeningServiceImpl реализует интерфейс ListeningService
+type ListeningServiceImpl struct {
+	db database.ListeningDB
+}
+
+// NewListeningService создает новый ListeningServiceImpl
+func NewListeningService(db database.ListeningDB) *ListeningServiceImpl {
+	return &ListeningServiceImpl{db: db}
+}
+
+// AddFavorite добавляет песню в избранное пользователя
+func (s *ListeningServiceImpl) AddFavorite(ctx context.Context, userID, songID int) error {
+	if songID <= 0 {
+		return fmt.Errorf("%w: не указан ID песни", models.ErrInvalidInput)
+	}
+	return s.db.AddFavorite(ctx, userID, songID)
+}
+
+// RemoveFavorite удаляет песню из избранного пользователя
+func (s *ListeningServiceImpl) RemoveFavorite(ctx context.Context, userID, songID int) error {
+	return s.db.RemoveFavorite(ctx, userID, songID)
+}
+
+// GetFavorites получает избранные песни пользователя, недавно добавленные первыми
+func (s *ListeningServiceImpl) GetFavorites(ctx context.Context, userID, limit, offset int) ([]models.Favorite, error) {
+	return s.db.GetFavorites(ctx, userID, limit, offset)
+}
+
+// RateSong проверяет оценку и сохраняет ее вместо прежней оценки пользователя
+func (s *ListeningServiceImpl) RateSong(ctx context.Context, userID, songID, rating int) (models.SongRating, error) {
+	if rating < models.MinRating || rating > models.MaxRating {
+		return models.SongRating{}, fmt.Errorf("%w: оценка должна быть от %d до %d", models.ErrInvalidInput, models.MinRating, models.MaxRating)
+	}
+	return s.db.SetRating(ctx, userID, songID, rating)
+}
+
+// GetRating получает оценку песни пользователем
+func (s *ListeningServiceImpl) GetRating(ctx context.Context, userID, songID int) (models.SongRating, error) {
+	return s.db.GetRating(ctx, userID, songID)
+}
+
+// DeleteRating удаляет оценку песни пользователем
+func (s *ListeningServiceImpl) DeleteRating(ctx context.Context, userID, songID int) error {
+	return s.db.DeleteRating(ctx, userID, songID)
+}
+
+// RecordPlay записывает прослушивание. Время прослушивания из будущего отклоняется,
+// чтобы клиент не мог накрутить популярность заранее.
+func (s *ListeningServiceImpl) RecordPlay(ctx context.Context, userID int, req models.PlayRequest) (int64, error) {
+	if req.SongID <= 0 {
+		return 0, fmt.Errorf("%w: не указан ID песни", models.ErrInvalidInput)
+	}
+
+	playedAt := time.Now()
+	if req.PlayedAt != nil {
+		if req.PlayedAt.After(playedAt.Add(maxPlayClockSkew)) {
+			return 0, fmt.Errorf("%w: время прослушивания в будущем", models.ErrInvalidInput)
+		}
+		playedAt = *req.PlayedAt
+	}
+	return s.db.AddPlay(ctx, userID, req.SongID, playedAt)
+}
+
+// GetHistory получает историю прослушиваний пользователя, последние первыми
+func (s *ListeningServiceImpl) GetHistory(ctx context.Context, userID, limit, offset int) ([]models.Play, error) {
+	return s.db.GetPlays(ctx, userID, limit, offset)
+}
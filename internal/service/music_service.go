@@ -2,91 +2,88 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"music_library/internal/database"
 	"music_library/internal/models"
-	"net/http"
-	"os"
+	"music_library/internal/provider"
 	"strings"
 )
 
+// maxVersesPerSong - ограничение на количество куплетов, загружаемых для сравнения текстов
+const maxVersesPerSong = 1000
+
 // MusicServiceImpl реализует интерфейс MusicService
 type MusicServiceImpl struct {
-	db database.SongDB
+	db      database.SongDB
+	details provider.DetailsProvider
 }
 
 // NewMusicService создает новый MusicServiceImpl
-func NewMusicService(db database.SongDB) *MusicServiceImpl {
-	return &MusicServiceImpl{db: db}
+func NewMusicService(db database.SongDB, details provider.DetailsProvider) *MusicServiceImpl {
+	return &MusicServiceImpl{db: db, details: details}
 }
 
-// AddSong добавляет новую песню, предварительно получив информацию из внешнего API
-func (s *MusicServiceImpl) AddSong(ctx context.Context, song models.Song) (int, error) {
+// AddSong добавляет новую песню, предварительно получив информацию из внешнего API.
+// Если информацию получить не удалось, песня сохраняется с данными пользователя
+// в статусе pending, и информация запрашивается позже фоновым обработчиком.
+// Если в библиотеке уже есть такая песня, возвращается models.DuplicateSongError,
+// force позволяет сохранить песню несмотря на дубликат.
+func (s *MusicServiceImpl) AddSong(ctx context.Context, song models.Song, force bool) (int, error) {
 	log.Printf("Добавление песни: %+v", song)
+	song.EnrichmentStatus = models.EnrichmentEnriched
 	details, err := s.GetSongDetails(ctx, song.Group, song.Song)
-	if err != nil {
-		log.Printf("Ошибка получения деталей песни: %v", err)
-		return 0, fmt.Errorf("ошибка получения деталей песни: %w", err)
+	switch {
+	case provider.IsNotFound(err):
+		log.Printf("Информация о песне не найдена: %v", err)
+		song.EnrichmentStatus = models.EnrichmentFailed
+	case err != nil:
+		log.Printf("Ошибка получения деталей песни, повтор будет выполнен позже: %v", err)
+		song.EnrichmentStatus = models.EnrichmentPending
 	}
 
-	song.ReleaseDate = details.ReleaseDate
-	song.Link = details.Link
+	if !force {
+		if err := s.findDuplicate(ctx, song, details.Text); err != nil {
+			log.Printf("Песня не добавлена: %v", err)
+			return 0, err
+		}
+	}
+
+	song.Provenance = details.Provenance
+	if details.ReleaseDate != "" {
+		song.ReleaseDate = details.ReleaseDate
+	} else if song.ReleaseDate != "" {
+		song.Provenance.ReleaseDate = models.SourceUser
+	}
+	if details.Link != "" {
+		song.Link = details.Link
+	} else if song.Link != "" {
+		song.Provenance.Link = models.SourceUser
+	}
 
-	id, err := s.db.AddSong(ctx, song)
+	id, err := s.db.AddSong(ctx, song, force)
 	if err != nil {
 		log.Printf("Ошибка добавления песни в БД: %v", err)
 		return 0, fmt.Errorf("ошибка добавления песни в БД: %w", err)
 	}
 
-	versesText := splitIntoVerses(details.Text)
-	verses := make([]models.Verse, len(versesText))
-	for i, v := range versesText {
-		verses[i] = models.Verse{
-			SongID:      id,
-			VerseNumber: i + 1,
-			Text:        v,
+	if details.Text != "" {
+		if err = s.db.AddVerses(ctx, id, versesFromText(id, details.Text), details.Provenance.Text); err != nil {
+			log.Printf("Ошибка добавления куплетов: %v", err)
+			return 0, fmt.Errorf("ошибка добавления куплетов: %w", err)
 		}
 	}
 
-	if err = s.db.AddVerses(ctx, id, verses); err != nil {
-		log.Printf("Ошибка добавления куплетов: %v", err)
-		return 0, fmt.Errorf("ошибка добавления куплетов: %w", err)
-	}
-
 	log.Printf("Песня успешно добавлена. ID: %d", id)
 	return id, nil
 }
 
-// GetSongDetails получает информацию о песне из внешнего API
+// GetSongDetails получает информацию о песне из настроенного источника
 func (s *MusicServiceImpl) GetSongDetails(ctx context.Context, group, songTitle string) (models.SongDetails, error) {
-	apiUrl := fmt.Sprintf("%s/info?group=%s&song=%s", os.Getenv("API_URL"), group, songTitle)
-	log.Printf("Запрос к внешнему API: %s", apiUrl)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
-	if err != nil {
-		log.Printf("Ошибка создания запроса к API: %v", err)
-		return models.SongDetails{}, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	client := http.Client{}
-	resp, err := client.Do(req)
+	details, err := s.details.GetSongDetails(ctx, group, songTitle)
 	if err != nil {
-		log.Printf("Ошибка выполнения запроса к API: %v", err)
-		return models.SongDetails{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Неуспешный статус код от API: %d", resp.StatusCode)
-		return models.SongDetails{}, fmt.Errorf("неуспешный статус код: %d", resp.StatusCode)
-	}
-
-	var details models.SongDetails
-	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
-		log.Printf("Ошибка декодирования JSON от API: %v", err)
-		return models.SongDetails{}, fmt.Errorf("ошибка декодирования JSON: %w", err)
+		return models.SongDetails{}, err
 	}
 
 	log.Printf("Детали песни получены: %+v", details)
@@ -94,13 +91,37 @@ func (s *MusicServiceImpl) GetSongDetails(ctx context.Context, group, songTitle
 }
 
 // GetSongs получает список песен из базы данных.
-func (s *MusicServiceImpl) GetSongs(ctx context.Context, limit, offset int, filter models.Song) ([]models.Song, error) {
-	return s.db.GetSongs(ctx, limit, offset, filter)
+// sort может быть пустым (без сортировки), popularity или rating.
+func (s *MusicServiceImpl) GetSongs(ctx context.Context, limit, offset int, filter models.Song, sort string) ([]models.Song, error) {
+	switch sort {
+	case "", models.SongSortPopularity, models.SongSortRating:
+	default:
+		return nil, fmt.Errorf("%w: неизвестная сортировка %q, ожидается popularity или rating", models.ErrInvalidInput, sort)
+	}
+	return s.db.GetSongs(ctx, limit, offset, filter, sort)
+}
+
+// ExportSongs передает в fn песни, подходящие под фильтр, вместе с куплетами.
+func (s *MusicServiceImpl) ExportSongs(ctx context.Context, filter models.Song, fn func(models.Song) error) error {
+	return s.db.ExportSongs(ctx, filter, fn)
 }
 
 // GetSongByID получает песню по ID из базы данных.
+// Если песня была присоединена к другой песне, возвращается models.SongMovedError.
 func (s *MusicServiceImpl) GetSongByID(ctx context.Context, id int) (models.Song, error) {
-	return s.db.GetSongByID(ctx, id)
+	song, err := s.db.GetSongByID(ctx, id)
+	if !errors.Is(err, models.ErrNotFound) {
+		return song, err
+	}
+
+	targetID, redirectErr := s.db.GetSongRedirect(ctx, id)
+	if redirectErr != nil {
+		if errors.Is(redirectErr, models.ErrNotFound) {
+			return models.Song{}, err
+		}
+		return models.Song{}, redirectErr
+	}
+	return models.Song{}, &models.SongMovedError{ID: id, TargetID: targetID}
 }
 
 // UpdateSong обновляет данные песни в базе данных.
@@ -113,9 +134,18 @@ func (s *MusicServiceImpl) DeleteSong(ctx context.Context, id int) error {
 	return s.db.DeleteSong(ctx, id)
 }
 
-// AddVerses добавляет куплеты к песне.
+// AddVerses добавляет куплеты к песне. Текст песни считается введенным пользователем
+// и не заменяется при обновлении информации из источника.
 func (s *MusicServiceImpl) AddVerses(ctx context.Context, songID int, verses []models.Verse) error {
-	return s.db.AddVerses(ctx, songID, verses)
+	return s.db.AddVerses(ctx, songID, verses, models.SourceUser)
+}
+
+// UpdateVerse изменяет текст куплета песни. Аннотации к куплету переносятся на новый текст.
+func (s *MusicServiceImpl) UpdateVerse(ctx context.Context, songID, verseNumber int, text string) (models.Verse, error) {
+	if strings.TrimSpace(text) == "" {
+		return models.Verse{}, fmt.Errorf("%w: текст куплета не может быть пустым", models.ErrInvalidInput)
+	}
+	return s.db.UpdateVerse(ctx, songID, verseNumber, text)
 }
 
 // GetVerses получает куплеты песни с пагинацией.
@@ -123,6 +153,51 @@ func (s *MusicServiceImpl) GetVerses(ctx context.Context, songID, limit, offset
 	return s.db.GetVersesBySongID(ctx, songID, limit, offset)
 }
 
+// MergeSongs присоединяет песню-дубликат к сохраняемой песне по правилам слияния полей.
+func (s *MusicServiceImpl) MergeSongs(ctx context.Context, survivorID int, req models.MergeRequest) error {
+	if req.SourceID == survivorID {
+		return fmt.Errorf("%w: нельзя присоединить песню к самой себе", models.ErrInvalidInput)
+	}
+	if err := req.Policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+	return s.db.MergeSongs(ctx, survivorID, req.SourceID, req.Policy.WithDefaults())
+}
+
+// GetSongMerges получает журнал слияний песни.
+func (s *MusicServiceImpl) GetSongMerges(ctx context.Context, survivorID int) ([]models.SongMerge, error) {
+	return s.db.GetSongMerges(ctx, survivorID)
+}
+
+// GetRevisions получает историю изменений песни с пагинацией.
+func (s *MusicServiceImpl) GetRevisions(ctx context.Context, songID, limit, offset int) ([]models.SongRevision, error) {
+	return s.db.GetRevisions(ctx, songID, limit, offset)
+}
+
+// GetRevision получает ревизию песни по номеру.
+func (s *MusicServiceImpl) GetRevision(ctx context.Context, songID, revision int) (models.SongRevision, error) {
+	return s.db.GetRevision(ctx, songID, revision)
+}
+
+// RevertToRevision восстанавливает песню и ее куплеты из ревизии.
+func (s *MusicServiceImpl) RevertToRevision(ctx context.Context, songID, revision int) error {
+	return s.db.RevertToRevision(ctx, songID, revision)
+}
+
+// versesFromText разбивает текст песни на пронумерованные куплеты
+func versesFromText(songID int, text string) []models.Verse {
+	versesText := splitIntoVerses(text)
+	verses := make([]models.Verse, len(versesText))
+	for i, v := range versesText {
+		verses[i] = models.Verse{
+			SongID:      songID,
+			VerseNumber: i + 1,
+			Text:        v,
+		}
+	}
+	return verses
+}
+
 // splitIntoVerses разбивает текст песни на куплеты по двойному переносу строки.
 func splitIntoVerses(text string) []string {
 	return strings.Split(text, "\n\n")
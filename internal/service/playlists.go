@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music_library/internal/database"
+	"music_library/internal/models"
+	"music_library/internal/normalize"
+	"strings"
+)
+
+// PlaylistServiceImpl реализует интерфейс PlaylistService
+type PlaylistServiceImpl struct {
+	db database.PlaylistDB
+}
+
+// NewPlaylistService создает новый PlaylistServiceImpl
+func NewPlaylistService(db database.PlaylistDB) *PlaylistServiceImpl {
+	return &PlaylistServiceImpl{db: db}
+}
+
+// CreatePlaylist создает пустой плейлист
+func (s *PlaylistServiceImpl) CreatePlaylist(ctx context.Context, playlist models.Playlist) (int, error) {
+	if err := validatePlaylist(&playlist); err != nil {
+		return 0, err
+	}
+	playlist.Items = nil
+	return s.db.CreatePlaylist(ctx, playlist)
+}
+
+// GetPlaylists получает плейлисты без элементов с пагинацией
+func (s *PlaylistServiceImpl) GetPlaylists(ctx context.Context, limit, offset int) ([]models.Playlist, error) {
+	return s.db.GetPlaylists(ctx, limit, offset)
+}
+
+// GetPlaylist получает плейлист с песнями в порядке позиций
+func (s *PlaylistServiceImpl) GetPlaylist(ctx context.Context, id int) (models.Playlist, error) {
+	return s.db.GetPlaylist(ctx, id)
+}
+
+// UpdatePlaylist изменяет название и описание плейлиста
+func (s *PlaylistServiceImpl) UpdatePlaylist(ctx context.Context, playlist models.Playlist) error {
+	if err := validatePlaylist(&playlist); err != nil {
+		return err
+	}
+	return s.db.UpdatePlaylist(ctx, playlist)
+}
+
+// DeletePlaylist удаляет плейлист
+func (s *PlaylistServiceImpl) DeletePlaylist(ctx context.Context, id int) error {
+	return s.db.DeletePlaylist(ctx, id)
+}
+
+// AddPlaylistItem добавляет песню в плейлист и возвращает ID элемента
+func (s *PlaylistServiceImpl) AddPlaylistItem(ctx context.Context, playlistID int, req models.PlaylistItemRequest) (int, error) {
+	if req.SongID <= 0 {
+		return 0, fmt.Errorf("%w: не указан ID песни", models.ErrInvalidInput)
+	}
+	if req.Position < 0 {
+		return 0, fmt.Errorf("%w: позиция не может быть отрицательной", models.ErrInvalidInput)
+	}
+	return s.db.AddPlaylistItem(ctx, playlistID, req.SongID, req.Position)
+}
+
+// RemovePlaylistItem удаляет элемент плейлиста
+func (s *PlaylistServiceImpl) RemovePlaylistItem(ctx context.Context, playlistID, itemID int) error {
+	return s.db.RemovePlaylistItem(ctx, playlistID, itemID)
+}
+
+// MovePlaylistItem перемещает элемент плейлиста на позицию
+func (s *PlaylistServiceImpl) MovePlaylistItem(ctx context.Context, playlistID, itemID, position int) error {
+	return s.db.MovePlaylistItem(ctx, playlistID, itemID, position)
+}
+
+// ImportPlaylist сопоставляет записи файла плейлиста с песнями библиотеки по нормализованным
+// названиям исполнителя и песни и создает плейлист из найденных песен в порядке файла.
+// Записи без исполнителя или без подходящей песни возвращаются в списке unmatched.
+// При dryRun плейлист не создается.
+func (s *PlaylistServiceImpl) ImportPlaylist(ctx context.Context, name string, entries []models.PlaylistEntry, dryRun bool) (models.PlaylistImportReport, error) {
+	playlist := models.Playlist{Name: name}
+	if err := validatePlaylist(&playlist); err != nil {
+		return models.PlaylistImportReport{}, err
+	}
+
+	report := models.PlaylistImportReport{
+		DryRun:    dryRun,
+		Name:      playlist.Name,
+		Total:     len(entries),
+		Matched:   []models.PlaylistEntry{},
+		Unmatched: []models.PlaylistEntry{},
+	}
+
+	// Одна и та же песня может встречаться в плейлисте несколько раз
+	found := make(map[[2]string]int)
+	for _, entry := range entries {
+		key := [2]string{normalize.Key(entry.Artist), normalize.Key(entry.Title)}
+		if key[0] == "" || key[1] == "" {
+			report.Unmatched = append(report.Unmatched, entry)
+			continue
+		}
+
+		id, ok := found[key]
+		if !ok {
+			song, err := s.db.FindSongByKeys(ctx, key[0], key[1])
+			if err != nil && !errors.Is(err, models.ErrNotFound) {
+				return models.PlaylistImportReport{}, err
+			}
+			id = song.ID
+			found[key] = id
+		}
+		if id == 0 {
+			report.Unmatched = append(report.Unmatched, entry)
+			continue
+		}
+
+		entry.SongID = id
+		report.Matched = append(report.Matched, entry)
+		playlist.Items = append(playlist.Items, models.PlaylistItem{Song: models.Song{ID: id}})
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	id, err := s.db.CreatePlaylist(ctx, playlist)
+	if err != nil {
+		return models.PlaylistImportReport{}, err
+	}
+	report.PlaylistID = id
+	return report, nil
+}
+
+// validatePlaylist проверяет название плейлиста и убирает пробелы по краям
+func validatePlaylist(playlist *models.Playlist) error {
+	playlist.Name = strings.TrimSpace(playlist.Name)
+	if playlist.Name == "" {
+		return fmt.Errorf("%w: не указано название плейлиста", models.ErrInvalidInput)
+	}
+	return nil
+}
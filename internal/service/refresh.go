@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"music_library/internal/provider"
+)
+
+// RefreshSong повторно запрашивает информацию о песне у источника, минуя кэш,
+// и обновляет поля, которые пользователь не задавал вручную. Если dryRun равен true,
+// возвращаются изменения без сохранения.
+func (s *MusicServiceImpl) RefreshSong(ctx context.Context, id int, dryRun bool) (models.RefreshResult, error) {
+	song, err := s.db.GetSongByID(ctx, id)
+	if err != nil {
+		return models.RefreshResult{}, err
+	}
+
+	details, err := s.details.GetSongDetails(provider.WithoutCache(ctx), song.Group, song.Song)
+	if err != nil {
+		return models.RefreshResult{}, fmt.Errorf("ошибка получения информации о песне %d: %w", id, err)
+	}
+
+	return s.db.RefreshSong(ctx, id, details, versesFromText(id, details.Text), dryRun)
+}
+
+// RefreshSongs обновляет информацию о песнях, подходящих под фильтр.
+// Ошибка отдельной песни записывается в ее результат и не прерывает обработку остальных.
+func (s *MusicServiceImpl) RefreshSongs(ctx context.Context, limit, offset int, filter models.Song, dryRun bool) ([]models.RefreshResult, error) {
+	songs, err := s.db.GetSongs(ctx, limit, offset, filter, "")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.RefreshResult, 0, len(songs))
+	for _, song := range songs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := s.RefreshSong(ctx, song.ID, dryRun)
+		if err != nil {
+			log.Printf("Ошибка обновления информации о песне %d: %v", song.ID, err)
+			result = models.RefreshResult{SongID: song.ID, Group: song.Group, Song: song.Song, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
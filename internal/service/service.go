@@ -2,16 +2,20 @@ package service
 
 import (
 	"context"
+	"music_library/internal/importer"
 	"music_library/internal/models"
 )
 
 // MusicService описывает интерфейс сервиса для работы с музыкальной библиотекой
 type MusicService interface {
-	// AddSong добавляет новую песню
-	AddSong(ctx context.Context, song models.Song) (int, error)
+	// AddSong добавляет новую песню, force разрешает сохранить дубликат
+	AddSong(ctx context.Context, song models.Song, force bool) (int, error)
 
-	// GetSongs получает список песен с фильтрацией и пагинацией
-	GetSongs(ctx context.Context, limit, offset int, filter models.Song) ([]models.Song, error)
+	// GetSongs получает список песен с фильтрацией, сортировкой и пагинацией
+	GetSongs(ctx context.Context, limit, offset int, filter models.Song, sort string) ([]models.Song, error)
+
+	// ExportSongs передает в fn песни, подходящие под фильтр, вместе с куплетами
+	ExportSongs(ctx context.Context, filter models.Song, fn func(models.Song) error) error
 
 	// GetSongByID получает песню по ID
 	GetSongByID(ctx context.Context, id int) (models.Song, error)
@@ -25,9 +29,183 @@ type MusicService interface {
 	// AddVerses добавляет куплеты к песне
 	AddVerses(ctx context.Context, songID int, verses []models.Verse) error
 
+	// UpdateVerse изменяет текст куплета песни
+	UpdateVerse(ctx context.Context, songID, verseNumber int, text string) (models.Verse, error)
+
 	// GetVerses получает куплеты песни с пагинацией
 	GetVerses(ctx context.Context, songID, limit, offset int) ([]models.Verse, error)
 
-	// GetSongDetails получает информацию о песне из внешнего API
+	// GetSongDetails получает информацию о песне из настроенного источника
 	GetSongDetails(ctx context.Context, group, song string) (models.SongDetails, error)
+
+	// GetRevisions получает историю изменений песни с пагинацией
+	GetRevisions(ctx context.Context, songID, limit, offset int) ([]models.SongRevision, error)
+
+	// GetRevision получает ревизию песни по номеру
+	GetRevision(ctx context.Context, songID, revision int) (models.SongRevision, error)
+
+	// RevertToRevision восстанавливает песню из ревизии
+	RevertToRevision(ctx context.Context, songID, revision int) error
+
+	// DiffRevisions возвращает построчные отличия куплетов между ревизиями
+	DiffRevisions(ctx context.Context, songID, from, to int) (models.LyricsDiff, error)
+
+	// MergeSongs присоединяет песню-дубликат к сохраняемой песне
+	MergeSongs(ctx context.Context, survivorID int, req models.MergeRequest) error
+
+	// GetSongMerges получает журнал слияний песни
+	GetSongMerges(ctx context.Context, survivorID int) ([]models.SongMerge, error)
+
+	// ImportSongs добавляет или обновляет песни из файла импорта
+	ImportSongs(ctx context.Context, rows importer.Reader, opts models.ImportOptions) (models.ImportReport, error)
+
+	// RefreshSong повторно получает информацию о песне и обновляет ее, dryRun только возвращает изменения
+	RefreshSong(ctx context.Context, id int, dryRun bool) (models.RefreshResult, error)
+
+	// RefreshSongs повторно получает информацию о песнях, подходящих под фильтр
+	RefreshSongs(ctx context.Context, limit, offset int, filter models.Song, dryRun bool) ([]models.RefreshResult, error)
+}
+
+// JobService описывает интерфейс сервиса фоновых задач
+type JobService interface {
+	// EnqueueSong ставит в очередь задачу создания песни
+	EnqueueSong(ctx context.Context, song models.Song, force bool) (int, error)
+
+	// GetJob получает задачу по ID
+	GetJob(ctx context.Context, id int) (models.Job, error)
+}
+
+// ListeningService описывает интерфейс сервиса избранного, оценок и истории прослушиваний
+type ListeningService interface {
+	// AddFavorite добавляет песню в избранное пользователя
+	AddFavorite(ctx context.Context, userID, songID int) error
+
+	// RemoveFavorite удаляет песню из избранного пользователя
+	RemoveFavorite(ctx context.Context, userID, songID int) error
+
+	// GetFavorites получает избранные песни пользователя с пагинацией
+	GetFavorites(ctx context.Context, userID, limit, offset int) ([]models.Favorite, error)
+
+	// RateSong сохраняет оценку песни пользователем
+	RateSong(ctx context.Context, userID, songID, rating int) (models.SongRating, error)
+
+	// GetRating получает оценку песни пользователем
+	GetRating(ctx context.Context, userID, songID int) (models.SongRating, error)
+
+	// DeleteRating удаляет оценку песни пользователем
+	DeleteRating(ctx context.Context, userID, songID int) error
+
+	// RecordPlay записывает прослушивание песни пользователем
+	RecordPlay(ctx context.Context, userID int, req models.PlayRequest) (int64, error)
+
+	// GetHistory получает историю прослушиваний пользователя с пагинацией
+	GetHistory(ctx context.Context, userID, limit, offset int) ([]models.Play, error)
+}
+
+// AnnotationService описывает интерфейс сервиса аннотаций к тексту песен
+type AnnotationService interface {
+	// GetAnnotations получает аннотации песни с деревьями ответов
+	GetAnnotations(ctx context.Context, songID int) ([]models.Annotation, error)
+
+	// CreateAnnotation добавляет аннотацию к диапазону символов куплета
+	CreateAnnotation(ctx context.Context, songID int, req models.AnnotationRequest) (models.Annotation, error)
+
+	// CreateReply добавляет ответ на аннотацию
+	CreateReply(ctx context.Context, songID, parentID int, req models.ReplyRequest) (models.Annotation, error)
+
+	// UpdateAnnotation изменяет аннотацию
+	UpdateAnnotation(ctx context.Context, songID, id int, upd models.AnnotationUpdate) (models.Annotation, error)
+
+	// DeleteAnnotation удаляет аннотацию вместе с ответами
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+}
+
+// AuditService описывает интерфейс сервиса журнала аудита
+type AuditService interface {
+	// GetAuditLog получает записи журнала аудита по условиям отбора с пагинацией
+	GetAuditLog(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error)
+}
+
+// PlaylistService описывает интерфейс сервиса плейлистов
+type PlaylistService interface {
+	// CreatePlaylist создает пустой плейлист
+	CreatePlaylist(ctx context.Context, playlist models.Playlist) (int, error)
+
+	// GetPlaylists получает плейлисты без элементов с пагинацией
+	GetPlaylists(ctx context.Context, limit, offset int) ([]models.Playlist, error)
+
+	// GetPlaylist получает плейлист с песнями в порядке позиций
+	GetPlaylist(ctx context.Context, id int) (models.Playlist, error)
+
+	// UpdatePlaylist изменяет название и описание плейлиста
+	UpdatePlaylist(ctx context.Context, playlist models.Playlist) error
+
+	// DeletePlaylist удаляет плейлист
+	DeletePlaylist(ctx context.Context, id int) error
+
+	// AddPlaylistItem добавляет песню в плейлист и возвращает ID элемента
+	AddPlaylistItem(ctx context.Context, playlistID int, req models.PlaylistItemRequest) (int, error)
+
+	// RemovePlaylistItem удаляет элемент плейлиста
+	RemovePlaylistItem(ctx context.Context, playlistID, itemID int) error
+
+	// MovePlaylistItem перемещает элемент плейлиста на позицию
+	MovePlaylistItem(ctx context.Context, playlistID, itemID, position int) error
+
+	// ImportPlaylist сопоставляет записи файла плейлиста с песнями библиотеки и создает из них плейлист
+	ImportPlaylist(ctx context.Context, name string, entries []models.PlaylistEntry, dryRun bool) (models.PlaylistImportReport, error)
+
+	// CreateSmartList проверяет правило и сохраняет умный список
+	CreateSmartList(ctx context.Context, list models.SmartList) (int, error)
+
+	// GetSmartLists получает умные списки без песен с пагинацией
+	GetSmartLists(ctx context.Context, limit, offset int) ([]models.SmartList, error)
+
+	// GetSmartList получает умный список с песнями, отобранными по его правилу
+	GetSmartList(ctx context.Context, id, limit, offset int) (models.SmartList, error)
+
+	// UpdateSmartList проверяет правило и изменяет умный список
+	UpdateSmartList(ctx context.Context, list models.SmartList) error
+
+	// DeleteSmartList удаляет умный список
+	DeleteSmartList(ctx context.Context, id int) error
+}
+
+// AuthService описывает интерфейс сервиса пользователей и аутентификации
+type AuthService interface {
+	// CreateUser регистрирует пользователя с указанной ролью
+	CreateUser(ctx context.Context, req models.UserRequest) (int, error)
+
+	// GetUsers получает всех пользователей
+	GetUsers(ctx context.Context) ([]models.User, error)
+
+	// SetUserRole изменяет роль пользователя
+	SetUserRole(ctx context.Context, id int, role string) error
+
+	// DeleteUser удаляет пользователя
+	DeleteUser(ctx context.Context, id int) error
+
+	// Login проверяет имя и пароль и выдает токены
+	Login(ctx context.Context, username, password string) (models.TokenPair, error)
+
+	// Refresh выдает новые токены по токену обновления
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+
+	// Logout отзывает токен обновления
+	Logout(ctx context.Context, refreshToken string) error
+
+	// Authenticate проверяет токен доступа и возвращает пользователя
+	Authenticate(ctx context.Context, accessToken string) (models.User, error)
+
+	// CreateAPIKey создает ключ API, открытый ключ возвращается только здесь
+	CreateAPIKey(ctx context.Context, req models.APIKeyRequest) (models.CreatedAPIKey, error)
+
+	// GetAPIKeys получает все ключи API
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+
+	// RevokeAPIKey отзывает ключ API
+	RevokeAPIKey(ctx context.Context, id int) error
+
+	// AuthenticateAPIKey проверяет ключ API и возвращает его описание
+	AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error)
 }
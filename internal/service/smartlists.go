@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"music_library/internal/models"
+	"strings"
+)
+
+// CreateSmartList проверяет правило и сохраняет умный список
+func (s *PlaylistServiceImpl) CreateSmartList(ctx context.Context, list models.SmartList) (int, error) {
+	if err := validateSmartList(&list); err != nil {
+		return 0, err
+	}
+	return s.db.CreateSmartList(ctx, list)
+}
+
+// GetSmartLists получает умные списки без песен с пагинацией
+func (s *PlaylistServiceImpl) GetSmartLists(ctx context.Context, limit, offset int) ([]models.SmartList, error) {
+	return s.db.GetSmartLists(ctx, limit, offset)
+}
+
+// GetSmartList получает умный список и отбирает песни по его правилу. Правило применяется
+// при каждом чтении, поэтому список учитывает песни, добавленные и измененные после его сохранения.
+func (s *PlaylistServiceImpl) GetSmartList(ctx context.Context, id, limit, offset int) (models.SmartList, error) {
+	list, err := s.db.GetSmartList(ctx, id)
+	if err != nil {
+		return models.SmartList{}, err
+	}
+
+	list.Songs, err = s.db.GetSmartListSongs(ctx, list.Rules, limit, offset)
+	if err != nil {
+		return models.SmartList{}, err
+	}
+	return list, nil
+}
+
+// UpdateSmartList проверяет правило и изменяет умный список
+func (s *PlaylistServiceImpl) UpdateSmartList(ctx context.Context, list models.SmartList) error {
+	if err := validateSmartList(&list); err != nil {
+		return err
+	}
+	return s.db.UpdateSmartList(ctx, list)
+}
+
+// DeleteSmartList удаляет умный список
+func (s *PlaylistServiceImpl) DeleteSmartList(ctx context.Context, id int) error {
+	return s.db.DeleteSmartList(ctx, id)
+}
+
+// validateSmartList проверяет название и правило умного списка
+func validateSmartList(list *models.SmartList) error {
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		return fmt.Errorf("%w: не указано название умного списка", models.ErrInvalidInput)
+	}
+	if err := list.Rules.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+	}
+	list.Songs = nil
+	return nil
+}
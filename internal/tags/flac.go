@@ -0,0 +1,91 @@
+package tags
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flacVorbisComment - тип блока метаданных FLAC с комментариями Vorbis
+const flacVorbisComment = 4
+
+// readFLAC читает комментарии Vorbis из блоков метаданных FLAC
+func readFLAC(r io.Reader) (Tags, error) {
+	if _, err := readFull(r, 4); err != nil {
+		return Tags{}, err
+	}
+
+	for {
+		header, err := readFull(r, 4)
+		if err != nil {
+			return Tags{}, err
+		}
+		last, blockType := header[0]&0x80 != 0, header[0]&0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		if blockType == flacVorbisComment {
+			block, err := readFull(r, size)
+			if err != nil {
+				return Tags{}, err
+			}
+			return parseVorbisComment(block)
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+			return Tags{}, fmt.Errorf("ошибка чтения метаданных: %w", err)
+		}
+		if last {
+			return Tags{}, fmt.Errorf("%w: в файле FLAC нет комментариев Vorbis", ErrUnsupported)
+		}
+	}
+}
+
+// parseVorbisComment разбирает блок комментариев Vorbis: строку производителя
+// и список строк вида КЛЮЧ=значение. Числа записаны в порядке little-endian.
+func parseVorbisComment(block []byte) (Tags, error) {
+	next := func() (string, bool) {
+		if len(block) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(block))
+		if n < 0 || 4+n > len(block) {
+			return "", false
+		}
+		value := string(block[4 : 4+n])
+		block = block[4+n:]
+		return value, true
+	}
+
+	if _, ok := next(); !ok {
+		return Tags{}, fmt.Errorf("%w: поврежден блок комментариев Vorbis", ErrUnsupported)
+	}
+	if len(block) < 4 {
+		return Tags{}, fmt.Errorf("%w: поврежден блок комментариев Vorbis", ErrUnsupported)
+	}
+	count := int(binary.LittleEndian.Uint32(block))
+	block = block[4:]
+
+	fields := make(map[string]string)
+	for i := 0; i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(comment, "=")
+		key = strings.ToUpper(key)
+		if ok && fields[key] == "" {
+			fields[key] = strings.TrimSpace(strings.ReplaceAll(value, "\r\n", "\n"))
+		}
+	}
+
+	tags := Tags{
+		Artist: firstNonEmpty(fields["ARTIST"], fields["ALBUMARTIST"]),
+		Title:  fields["TITLE"],
+		Date:   firstNonEmpty(fields["DATE"], fields["YEAR"]),
+		Lyrics: firstNonEmpty(fields["LYRICS"], fields["UNSYNCEDLYRICS"]),
+	}
+	if tags.Artist == "" && tags.Title == "" {
+		return Tags{}, fmt.Errorf("%w: в комментариях Vorbis нет исполнителя и названия", ErrUnsupported)
+	}
+	return tags, nil
+}
@@ -0,0 +1,204 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// Флаги заголовка ID3v2
+const (
+	id3Unsynchronisation = 0x80
+	id3ExtendedHeader    = 0x40
+)
+
+// id3Frames - идентификаторы кадров ID3v2.2 и ID3v2.3/2.4 для каждого поля
+var id3Frames = map[string]string{
+	"TP1": "artist", "TPE1": "artist",
+	"TT2": "title", "TIT2": "title",
+	"TYE": "year", "TYER": "year",
+	"TDRC": "date", "TDRL": "release",
+	"ULT": "lyrics", "USLT": "lyrics",
+}
+
+// readID3v2 читает тег ID3v2 версий 2.2, 2.3 и 2.4 в начале файла
+func readID3v2(r io.Reader) (Tags, error) {
+	header, err := readFull(r, 10)
+	if err != nil {
+		return Tags{}, err
+	}
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 {
+		return Tags{}, fmt.Errorf("%w: ID3v2.%d", ErrUnsupported, version)
+	}
+
+	data, err := readFull(r, syncsafe(header[6:10]))
+	if err != nil {
+		return Tags{}, err
+	}
+	// В версиях до 2.4 рассинхронизация применяется ко всему тегу
+	if flags&id3Unsynchronisation != 0 && version < 4 {
+		data = removeUnsync(data)
+	}
+	if flags&id3ExtendedHeader != 0 && version >= 3 {
+		data = skipExtendedHeader(data, version)
+	}
+
+	fields := make(map[string]string)
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for len(data) >= headerLen && data[0] != 0 {
+		id := string(data[:idLen])
+		var size int
+		switch version {
+		case 2:
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+		default:
+			size = syncsafe(data[4:8])
+		}
+		if size < 0 || headerLen+size > len(data) {
+			break
+		}
+
+		body := data[headerLen : headerLen+size]
+		if version == 4 && data[9]&0x02 != 0 {
+			body = removeUnsync(body)
+		}
+		// Сжатые и зашифрованные кадры пропускаются
+		compressed := (version == 3 && data[9]&0xc0 != 0) || (version == 4 && data[9]&0x0c != 0)
+		if field, ok := id3Frames[id]; ok && !compressed && fields[field] == "" {
+			if field == "lyrics" {
+				fields[field] = decodeLyricsFrame(body)
+			} else {
+				fields[field] = decodeTextFrame(body)
+			}
+		}
+		data = data[headerLen+size:]
+	}
+
+	tags := Tags{
+		Artist: fields["artist"],
+		Title:  fields["title"],
+		Date:   firstNonEmpty(fields["date"], fields["release"], fields["year"]),
+		Lyrics: fields["lyrics"],
+	}
+	if tags.Artist == "" && tags.Title == "" {
+		return Tags{}, fmt.Errorf("%w: в теге ID3v2 нет исполнителя и названия", ErrUnsupported)
+	}
+	return tags, nil
+}
+
+// syncsafe декодирует 28-битное число, в каждом байте которого используются 7 младших бит
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// removeUnsync удаляет байты 0x00, вставленные после 0xFF при рассинхронизации
+func removeUnsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// skipExtendedHeader пропускает расширенный заголовок тега
+func skipExtendedHeader(data []byte, version byte) []byte {
+	if len(data) < 4 {
+		return nil
+	}
+	size := int(binary.BigEndian.Uint32(data[:4]))
+	if version == 3 {
+		// В версии 2.3 размер не включает собственные 4 байта
+		size += 4
+	} else {
+		size = syncsafe(data[:4])
+	}
+	if size > len(data) {
+		return nil
+	}
+	return data[size:]
+}
+
+// decodeTextFrame декодирует текстовый кадр: байт кодировки и текст.
+// В версии 2.4 кадр может содержать несколько значений через нулевой символ, берется первое.
+func decodeTextFrame(body []byte) string {
+	if len(body) < 2 {
+		return ""
+	}
+	text := decodeText(body[0], body[1:])
+	text, _, _ = strings.Cut(text, "\x00")
+	return strings.TrimSpace(text)
+}
+
+// decodeLyricsFrame декодирует кадр USLT: кодировка, язык, описание и текст
+func decodeLyricsFrame(body []byte) string {
+	if len(body) < 5 {
+		return ""
+	}
+	encoding, rest := body[0], body[4:]
+	_, text := splitTerminated(encoding, rest)
+	return strings.TrimSpace(strings.ReplaceAll(decodeText(encoding, text), "\r\n", "\n"))
+}
+
+// splitTerminated отделяет строку, завершенную нулевым символом в кодировке encoding
+func splitTerminated(encoding byte, data []byte) ([]byte, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeText декодирует текст в кодировке ID3v2: 0 - ISO-8859-1, 1 - UTF-16 с BOM, 2 - UTF-16BE, 3 - UTF-8
+func decodeText(encoding byte, data []byte) string {
+	switch encoding {
+	case 0:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xff && data[1] == 0xfe:
+				bigEndian, data = false, data[2:]
+			case data[0] == 0xfe && data[1] == 0xff:
+				bigEndian, data = true, data[2:]
+			}
+		}
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(data[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			}
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	default:
+		return strings.TrimRight(string(data), "\x00")
+	}
+}
+
+// firstNonEmpty возвращает первое непустое значение
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+// Package tags читает метаданные аудиофайлов: ID3v2 в MP3 и комментарии Vorbis в FLAC.
+package tags
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported - формат файла не поддерживается или в файле нет метаданных
+var ErrUnsupported = errors.New("формат файла не поддерживается")
+
+// Tags - метаданные трека
+type Tags struct {
+	Artist string
+	Title  string
+	Date   string
+	Lyrics string
+}
+
+// Supported проверяет, что расширение файла поддерживается
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3", ".flac":
+		return true
+	default:
+		return false
+	}
+}
+
+// ReadFile читает метаданные MP3- или FLAC-файла. Формат определяется по сигнатуре файла.
+func ReadFile(path string) (Tags, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Tags{}, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	magic, err := r.Peek(4)
+	if err != nil {
+		return Tags{}, fmt.Errorf("%w: файл слишком короткий", ErrUnsupported)
+	}
+
+	switch {
+	case string(magic[:3]) == "ID3":
+		return readID3v2(r)
+	case string(magic) == "fLaC":
+		return readFLAC(r)
+	default:
+		return Tags{}, fmt.Errorf("%w: нет тегов ID3v2 или сигнатуры FLAC", ErrUnsupported)
+	}
+}
+
+// readFull читает ровно n байт
+func readFull(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("ошибка чтения метаданных: %w", err)
+	}
+	return buf, nil
+}
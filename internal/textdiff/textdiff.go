@@ -0,0 +1,169 @@
+// Package textdiff вычисляет построчные отличия между двумя текстами.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind определяет тип операции построчного сравнения
+type Kind int
+
+// Типы операций построчного сравнения
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Op описывает одну строку результата сравнения.
+// OldIndex и NewIndex - индексы строки в старом и новом тексте, -1 если строки там нет.
+type Op struct {
+	Kind     Kind
+	OldIndex int
+	NewIndex int
+	Text     string
+}
+
+// Lines сравнивает два набора строк по наибольшей общей подпоследовательности
+// и возвращает последовательность операций, превращающую a в b
+func Lines(a, b []string) []Op {
+	// lcs[i][j] - длина наибольшей общей подпоследовательности a[i:] и b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Kind: Equal, OldIndex: i, NewIndex: j, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Op{Kind: Delete, OldIndex: i, NewIndex: -1, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Kind: Insert, OldIndex: -1, NewIndex: j, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, Op{Kind: Delete, OldIndex: i, NewIndex: -1, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, Op{Kind: Insert, OldIndex: -1, NewIndex: j, Text: b[j]})
+	}
+
+	return ops
+}
+
+// SplitLines разбивает текст на строки. Пустой текст не содержит строк.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified возвращает отличия между a и b в формате unified diff
+// с указанным количеством строк контекста вокруг изменений.
+// Если тексты совпадают, возвращается пустая строка.
+func Unified(oldName, newName string, a, b []string, context int) string {
+	ops := Lines(a, b)
+
+	var sb strings.Builder
+	for _, h := range hunks(ops, context) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&sb, ops, h)
+	}
+	return sb.String()
+}
+
+// hunk - диапазон операций, который выводится одним блоком unified diff
+type hunk struct {
+	start, end int
+}
+
+// hunks группирует изменения в блоки, расширяя их строками контекста.
+// Блоки, между которыми не больше 2*context общих строк, объединяются.
+func hunks(ops []Op, context int) []hunk {
+	var result []hunk
+	for i, op := range ops {
+		if op.Kind == Equal {
+			continue
+		}
+		start := max(i-context, 0)
+		end := min(i+context+1, len(ops))
+		if n := len(result); n > 0 && start <= result[n-1].end {
+			result[n-1].end = max(result[n-1].end, end)
+			continue
+		}
+		result = append(result, hunk{start: start, end: end})
+	}
+	return result
+}
+
+// writeHunk выводит блок unified diff с заголовком диапазонов строк
+func writeHunk(sb *strings.Builder, ops []Op, h hunk) {
+	// Позиции начала блока - количество строк старого и нового текста перед ним
+	oldStart, newStart := 0, 0
+	for _, op := range ops[:h.start] {
+		if op.Kind != Insert {
+			oldStart++
+		}
+		if op.Kind != Delete {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[h.start:h.end] {
+		if op.Kind != Insert {
+			oldCount++
+		}
+		if op.Kind != Delete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, op := range ops[h.start:h.end] {
+		switch op.Kind {
+		case Equal:
+			sb.WriteString(" ")
+		case Delete:
+			sb.WriteString("-")
+		case Insert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(op.Text)
+		sb.WriteString("\n")
+	}
+}
+
+// hunkRange форматирует диапазон строк блока. Строки нумеруются с единицы,
+// пустой диапазон указывает на строку, после которой происходит вставка.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
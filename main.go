@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"music_library/internal/auth"
 	"music_library/internal/database"
 	"music_library/internal/handlers"
+	"music_library/internal/models"
+	"music_library/internal/provider"
 	"music_library/internal/service"
 	"net/http"
 	"os"
 
-	_ "music_library/docs" // swag документация для API
+	_ "music_library/docs"       // swag документация для API
+	_ "music_library/migrations" // Go-миграции базы данных
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -29,6 +34,17 @@ import (
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+
+// @security BearerAuth
+// @security APIKeyAuth
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Токен доступа из POST /auth/login в формате "Bearer <токен>"
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description Ключ API из POST /admin/api-keys
 func main() {
 
 	// Загрузка переменных окружения
@@ -62,10 +78,76 @@ func main() {
 		log.Fatalf("Ошибка применения миграций: %v", err)
 	}
 
+	// Источник информации о песнях
+	detailsConfig, err := provider.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации источника информации о песнях: %v", err)
+	}
+	details, err := provider.New(detailsConfig)
+	if err != nil {
+		log.Fatalf("Ошибка создания источника информации о песнях: %v", err)
+	}
+	switch detailsConfig.Cache.Kind {
+	case provider.CacheMemory:
+		details = provider.NewCachingProvider(details, provider.NewLRUCache(detailsConfig.Cache.Size), detailsConfig.Cache)
+	case provider.CachePostgres:
+		cache := database.NewPostgresDetailsCache(db)
+		go provider.RunCacheCleanup(context.Background(), cache, detailsConfig.Cache.CleanupInterval)
+		details = provider.NewCachingProvider(details, cache, detailsConfig.Cache)
+	}
+
 	// Создание сервиса и обработчика
 	repo := database.NewPostgresRepository(db)
-	musicService := service.NewMusicService(repo)
-	handler := handlers.NewHandler(musicService)
+	musicService := service.NewMusicService(repo, details)
+
+	// Команды без запуска сервера. Они не выдают и не проверяют токены, поэтому выполняются
+	// до проверки настроек аутентификации и не требуют AUTH_JWT_SECRET:
+	// music_library import [-format csv] [-mode insert] [-dry-run] <файл> - импорт песен из файла
+	// music_library scan [-dry-run] [-overwrite] <каталог> - импорт песен из тегов аудиофайлов
+	// music_library useradd [-role viewer|editor|admin] <имя> - регистрация пользователя, пароль читается из stdin
+	if len(os.Args) > 1 {
+		commands := map[string]func([]string) int{
+			"import":  func(args []string) int { return runImport(musicService, args) },
+			"scan":    func(args []string) int { return runScan(musicService, args) },
+			"useradd": func(args []string) int { return runUserAdd(service.NewAuthService(repo, nil), args) },
+		}
+		if command, ok := commands[os.Args[1]]; ok {
+			code := command(os.Args[2:])
+			db.Close()
+			os.Exit(code)
+		}
+	}
+
+	// Пользователи и аутентификация
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации аутентификации: %v", err)
+	}
+	authService := service.NewAuthService(repo, auth.NewTokens(authConfig))
+
+	// Пул обработчиков фоновых задач
+	jobConfig, err := service.JobConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации фоновых задач: %v", err)
+	}
+	jobService := service.NewJobService(repo, musicService, jobConfig)
+	go jobService.Run(context.Background())
+
+	handler := handlers.NewHandler(musicService, jobService)
+
+	// Фоновое получение информации для песен, сохраненных без нее
+	enrichmentConfig, err := service.EnrichmentConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации получения информации о песнях: %v", err)
+	}
+	go service.NewEnricher(repo, details, enrichmentConfig).Run(context.Background())
+
+	diagnostics := handlers.NewDiagnosticsHandler(details)
+	playlists := handlers.NewPlaylistHandler(service.NewPlaylistService(repo))
+	authHandler := handlers.NewAuthHandler(authService, authConfig.Required)
+	audit := handlers.NewAuditHandler(service.NewAuditService(repo))
+	listening := handlers.NewListeningHandler(service.NewListeningService(repo))
+	annotations := handlers.NewAnnotationHandler(service.NewAnnotationService(repo))
 
 	// Создание роутера
 	r := chi.NewRouter()
@@ -76,19 +158,110 @@ func main() {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
+	r.Use(handlers.Actor)
 
 	// Маршруты
 	r.Get("/", handler.RootHandler)
-	r.Route("/songs", func(r chi.Router) {
-		r.With(handlers.Paginate).Get("/", handler.GetSongs) // GET /songs - получение списка песен
-		r.Post("/", handler.CreateSong)                      // POST /songs - создание новой песни
-		r.Route("/{id}", func(r chi.Router) {                // Подмаршрутизация для /songs/{id}
-			r.Get("/", handler.GetSong)                                 // GET /songs/{id} - получение песни по ID
-			r.Put("/", handler.UpdateSong)                              // PUT /songs/{id} - обновление песни
-			r.Delete("/", handler.DeleteSong)                           // DELETE /songs/{id} - удаление песни
-			r.With(handlers.Paginate).Get("/verses", handler.GetVerses) // GET /songs/{id}/verses - получение куплетов с пагинацией
-			r.Post("/verses", handler.AddVerses)                        // POST /songs/{id}/verses - добавление куплетов
+	r.Route("/auth", func(r chi.Router) {
+		r.Post("/login", authHandler.Login)                         // POST /auth/login - вход по имени и паролю
+		r.Post("/refresh", authHandler.Refresh)                     // POST /auth/refresh - обновление токенов
+		r.Post("/logout", authHandler.Logout)                       // POST /auth/logout - отзыв токена обновления
+		r.With(authHandler.Authenticate).Get("/me", authHandler.Me) // GET /auth/me - текущий пользователь
+	})
+
+	// Права доступа к маршрутам: viewer может только читать, editor - также создавать и изменять,
+	// admin - также удалять и управлять пользователями и ключами API
+	read := authHandler.Authorize(models.PermRead)
+	edit := authHandler.Authorize(models.PermEdit)
+	remove := authHandler.Authorize(models.PermDelete)
+	admin := authHandler.Authorize(models.PermAdmin)
+
+	// Остальные маршруты требуют токен доступа или ключ API, если вход не отключен через AUTH_REQUIRED=false
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+
+		r.Route("/songs", func(r chi.Router) {
+			r.With(read, handlers.Paginate).Get("/", handler.GetSongs)             // GET /songs - получение списка песен
+			r.With(edit).Post("/", handler.CreateSong)                             // POST /songs - создание новой песни
+			r.With(edit, handlers.Paginate).Post("/refresh", handler.RefreshSongs) // POST /songs/refresh - обновление информации о песнях
+			r.Route("/{id}", func(r chi.Router) {                                  // Подмаршрутизация для /songs/{id}
+				r.With(read).Get("/", handler.GetSong)                                   // GET /songs/{id} - получение песни по ID
+				r.With(edit).Put("/", handler.UpdateSong)                                // PUT /songs/{id} - обновление песни
+				r.With(remove).Delete("/", handler.DeleteSong)                           // DELETE /songs/{id} - удаление песни
+				r.With(read, handlers.Paginate).Get("/verses", handler.GetVerses)        // GET /songs/{id}/verses - получение куплетов с пагинацией
+				r.With(edit).Post("/verses", handler.AddVerses)                          // POST /songs/{id}/verses - добавление куплетов
+				r.With(edit).Put("/verses/{number}", handler.UpdateVerse)                // PUT /songs/{id}/verses/{number} - изменение текста куплета
+				r.With(read, handlers.Paginate).Get("/revisions", handler.GetRevisions)  // GET /songs/{id}/revisions - история изменений песни
+				r.With(read).Get("/revisions/{rev}", handler.GetRevision)                // GET /songs/{id}/revisions/{rev} - получение ревизии
+				r.With(edit).Post("/revisions/{rev}/revert", handler.RevertToRevision)   // POST /songs/{id}/revisions/{rev}/revert - откат к ревизии
+				r.With(read).Get("/diff", handler.DiffRevisions)                         // GET /songs/{id}/diff - построчное сравнение ревизий
+				r.With(remove).Post("/merge", handler.MergeSong)                         // POST /songs/{id}/merge - слияние песни-дубликата
+				r.With(read).Get("/merges", handler.GetSongMerges)                       // GET /songs/{id}/merges - журнал слияний песни
+				r.With(edit).Post("/refresh", handler.RefreshSong)                       // POST /songs/{id}/refresh - обновление информации о песне
+				r.With(read).Get("/rating", listening.GetRating)                         // GET /songs/{id}/rating - своя оценка песни
+				r.With(read).Put("/rating", listening.RateSong)                          // PUT /songs/{id}/rating - оценка песни от 1 до 5
+				r.With(read).Delete("/rating", listening.DeleteRating)                   // DELETE /songs/{id}/rating - удаление своей оценки
+				r.With(read).Get("/annotations", annotations.GetAnnotations)             // GET /songs/{id}/annotations - аннотации к тексту с ответами
+				r.With(edit).Post("/annotations", annotations.CreateAnnotation)          // POST /songs/{id}/annotations - аннотация к фрагменту куплета
+				r.With(edit).Put("/annotations/{aid}", annotations.UpdateAnnotation)     // PUT /songs/{id}/annotations/{aid} - изменение аннотации
+				r.With(edit).Delete("/annotations/{aid}", annotations.DeleteAnnotation)  // DELETE /songs/{id}/annotations/{aid} - удаление аннотации с ответами
+				r.With(edit).Post("/annotations/{aid}/replies", annotations.CreateReply) // POST /songs/{id}/annotations/{aid}/replies - ответ на аннотацию
+			})
+		})
+
+		r.Route("/playlists", func(r chi.Router) {
+			r.With(read, handlers.Paginate).Get("/", playlists.GetPlaylists) // GET /playlists - получение списка плейлистов
+			r.With(edit).Post("/", playlists.CreatePlaylist)                 // POST /playlists - создание плейлиста
+			r.With(edit).Post("/import", playlists.ImportPlaylist)           // POST /playlists/import - импорт плейлиста из M3U или XSPF
+			r.Route("/{id}", func(r chi.Router) {                            // Подмаршрутизация для /playlists/{id}
+				r.With(read).Get("/", playlists.GetPlaylist)                        // GET /playlists/{id} - получение плейлиста с песнями
+				r.With(edit).Put("/", playlists.UpdatePlaylist)                     // PUT /playlists/{id} - изменение плейлиста
+				r.With(remove).Delete("/", playlists.DeletePlaylist)                // DELETE /playlists/{id} - удаление плейлиста
+				r.With(read).Get("/export", playlists.ExportPlaylist)               // GET /playlists/{id}/export - выгрузка плейлиста в M3U8 или XSPF
+				r.With(edit).Post("/items", playlists.AddPlaylistItem)              // POST /playlists/{id}/items - добавление песни
+				r.With(edit).Delete("/items/{item}", playlists.RemovePlaylistItem)  // DELETE /playlists/{id}/items/{item} - удаление песни
+				r.With(edit).Post("/items/{item}/move", playlists.MovePlaylistItem) // POST /playlists/{id}/items/{item}/move - перемещение песни
+			})
 		})
+
+		r.Route("/smart-lists", func(r chi.Router) {
+			r.With(read, handlers.Paginate).Get("/", playlists.GetSmartLists)    // GET /smart-lists - получение умных списков
+			r.With(edit).Post("/", playlists.CreateSmartList)                    // POST /smart-lists - создание умного списка
+			r.With(read, handlers.Paginate).Get("/{id}", playlists.GetSmartList) // GET /smart-lists/{id} - песни по правилу умного списка
+			r.With(edit).Put("/{id}", playlists.UpdateSmartList)                 // PUT /smart-lists/{id} - изменение умного списка
+			r.With(remove).Delete("/{id}", playlists.DeleteSmartList)            // DELETE /smart-lists/{id} - удаление умного списка
+		})
+
+		// Избранное, оценки и история принадлежат пользователю и доступны любой роли
+		r.Route("/me", func(r chi.Router) {
+			r.Use(read)
+			r.With(handlers.Paginate).Get("/favorites", listening.GetFavorites) // GET /me/favorites - избранные песни
+			r.Post("/favorites", listening.AddFavorite)                         // POST /me/favorites - добавление песни в избранное
+			r.Delete("/favorites/{id}", listening.RemoveFavorite)               // DELETE /me/favorites/{id} - удаление песни из избранного
+			r.With(handlers.Paginate).Get("/history", listening.GetHistory)     // GET /me/history - история прослушиваний
+			r.Post("/history", listening.RecordPlay)                            // POST /me/history - запись прослушивания
+		})
+
+		// Управление доступом - только для вошедших администраторов, даже если вход не обязателен
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(authHandler.RequireUser, admin)
+			r.Get("/users", authHandler.GetUsers)                // GET /admin/users - получение пользователей
+			r.Post("/users", authHandler.CreateUser)             // POST /admin/users - регистрация пользователя
+			r.Put("/users/{id}", authHandler.UpdateUserRole)     // PUT /admin/users/{id} - изменение роли пользователя
+			r.Delete("/users/{id}", authHandler.DeleteUser)      // DELETE /admin/users/{id} - удаление пользователя
+			r.Get("/api-keys", authHandler.GetAPIKeys)           // GET /admin/api-keys - получение ключей API
+			r.Post("/api-keys", authHandler.CreateAPIKey)        // POST /admin/api-keys - создание ключа API
+			r.Delete("/api-keys/{id}", authHandler.RevokeAPIKey) // DELETE /admin/api-keys/{id} - отзыв ключа API
+		})
+
+		r.With(edit).Post("/import", handler.ImportSongs) // POST /import - импорт песен из файла
+		r.With(read).Get("/export", handler.ExportSongs)  // GET /export - выгрузка песен с куплетами
+		r.With(read).Get("/jobs/{id}", handler.GetJob)    // GET /jobs/{id} - статус фоновой задачи
+
+		r.With(admin, handlers.Paginate).Get("/audit", audit.GetAuditLog) // GET /audit - журнал аудита изменений
+
+		r.With(read).Get("/diagnostics/providers", diagnostics.GetProviders) // GET /diagnostics/providers - состояние источников информации
+		r.With(read).Get("/diagnostics/cache", diagnostics.GetCache)         // GET /diagnostics/cache - счетчики кэша информации
 	})
 
 	// Запуск сервера
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    author TEXT NOT NULL,
+    action TEXT NOT NULL,
+    snapshot JSONB NOT NULL,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (song_id, revision)
+);
+
+-- +goose Down
+DROP TABLE song_revisions;
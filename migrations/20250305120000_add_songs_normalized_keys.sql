@@ -0,0 +1,16 @@
+-- +goose Up
+-- Нормализованные ключи заполняются приложением при создании и обновлении песни.
+-- Ключи уже существующих строк заполняет следующая миграция, она же создает уникальный индекс.
+ALTER TABLE songs
+    ADD COLUMN group_key TEXT,
+    ADD COLUMN song_key TEXT,
+    ADD COLUMN allow_duplicate BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX songs_group_key_idx ON songs (group_key);
+
+-- +goose Down
+DROP INDEX songs_group_key_idx;
+ALTER TABLE songs
+    DROP COLUMN allow_duplicate,
+    DROP COLUMN song_key,
+    DROP COLUMN group_key;
@@ -0,0 +1,81 @@
+// Package migrations содержит миграции базы данных, которые нельзя выразить на SQL.
+// SQL-миграции лежат в том же каталоге, goose применяет их вместе по порядку версий.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"music_library/internal/normalize"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upBackfillSongsNormalizedKeys, downBackfillSongsNormalizedKeys)
+}
+
+// upBackfillSongsNormalizedKeys заполняет нормализованные ключи песен, добавленных до их появления,
+// и создает уникальный индекс по ключам. Ключи вычисляются normalize.Key, как и при создании песни.
+// Если среди старых песен уже есть дубликаты, сохраняется самая ранняя, а остальные помечаются
+// allow_duplicate, чтобы их можно было найти и объединить через слияние.
+func upBackfillSongsNormalizedKeys(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, "group", song FROM songs WHERE group_key IS NULL ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("ошибка получения песен без ключей: %w", err)
+	}
+
+	type songKeys struct {
+		id                int
+		groupKey, songKey string
+	}
+	var songs []songKeys
+	for rows.Next() {
+		var id int
+		var group, song string
+		if err := rows.Scan(&id, &group, &song); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка чтения песни без ключей: %w", err)
+		}
+		songs = append(songs, songKeys{id: id, groupKey: normalize.Key(group), songKey: normalize.Key(song)})
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("ошибка чтения песен без ключей: %w", err)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения песен без ключей: %w", err)
+	}
+
+	duplicates := 0
+	for _, s := range songs {
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM songs WHERE group_key = $1 AND song_key = $2 AND NOT allow_duplicate)`
+		if err := tx.QueryRowContext(ctx, query, s.groupKey, s.songKey).Scan(&exists); err != nil {
+			return fmt.Errorf("ошибка поиска дубликата песни %d: %w", s.id, err)
+		}
+		if exists {
+			duplicates++
+		}
+
+		query = `UPDATE songs SET group_key = $2, song_key = $3, allow_duplicate = allow_duplicate OR $4 WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, query, s.id, s.groupKey, s.songKey, exists); err != nil {
+			return fmt.Errorf("ошибка заполнения ключей песни %d: %w", s.id, err)
+		}
+	}
+	log.Printf("Ключи заполнены для %d песен, дубликатов среди них: %d", len(songs), duplicates)
+
+	query := `CREATE UNIQUE INDEX IF NOT EXISTS songs_normalized_key_idx ON songs (group_key, song_key) WHERE NOT allow_duplicate`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("ошибка создания уникального индекса по ключам песен: %w", err)
+	}
+	return nil
+}
+
+// downBackfillSongsNormalizedKeys удаляет уникальный индекс, ключи удаляются предыдущей миграцией
+func downBackfillSongsNormalizedKeys(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS songs_normalized_key_idx`); err != nil {
+		return fmt.Errorf("ошибка удаления уникального индекса по ключам песен: %w", err)
+	}
+	return nil
+}
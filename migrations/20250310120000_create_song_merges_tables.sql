@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE song_merges (
+    id SERIAL PRIMARY KEY,
+    survivor_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    merged_id INTEGER NOT NULL,
+    author TEXT NOT NULL,
+    policy JSONB NOT NULL,
+    merged_snapshot JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX song_merges_survivor_id_idx ON song_merges (survivor_id);
+
+CREATE TABLE song_redirects (
+    old_id INTEGER PRIMARY KEY,
+    target_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX song_redirects_target_id_idx ON song_redirects (target_id);
+
+-- +goose Down
+DROP TABLE song_redirects;
+DROP TABLE song_merges;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE songs
+    ADD COLUMN release_date_source TEXT NOT NULL DEFAULT '',
+    ADD COLUMN text_source TEXT NOT NULL DEFAULT '',
+    ADD COLUMN link_source TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE songs
+    DROP COLUMN link_source,
+    DROP COLUMN text_source,
+    DROP COLUMN release_date_source;
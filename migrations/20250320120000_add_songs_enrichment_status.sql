@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE songs
+    ADD COLUMN enrichment_status TEXT NOT NULL DEFAULT 'enriched',
+    ADD COLUMN enrichment_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN enrichment_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN next_enrichment_at TIMESTAMPTZ;
+
+CREATE INDEX songs_pending_enrichment_idx ON songs (next_enrichment_at) WHERE enrichment_status = 'pending';
+
+-- +goose Down
+DROP INDEX songs_pending_enrichment_idx;
+ALTER TABLE songs
+    DROP COLUMN next_enrichment_at,
+    DROP COLUMN enrichment_error,
+    DROP COLUMN enrichment_attempts,
+    DROP COLUMN enrichment_status;
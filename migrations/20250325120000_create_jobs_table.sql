@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_queued_idx ON jobs (id) WHERE status = 'queued';
+
+-- +goose Down
+DROP TABLE jobs;
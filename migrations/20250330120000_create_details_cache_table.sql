@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE details_cache (
+    key TEXT PRIMARY KEY,
+    release_date TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    link TEXT NOT NULL DEFAULT '',
+    release_date_source TEXT NOT NULL DEFAULT '',
+    text_source TEXT NOT NULL DEFAULT '',
+    link_source TEXT NOT NULL DEFAULT '',
+    not_found BOOLEAN NOT NULL DEFAULT false,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE details_cache;
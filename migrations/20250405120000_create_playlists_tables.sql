@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE playlists (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Позиции элементов идут подряд с единицы. Проверка уникальности отложена до конца транзакции,
+-- чтобы элементы можно было сдвигать одним запросом.
+CREATE TABLE playlist_items (
+    id SERIAL PRIMARY KEY,
+    playlist_id INT NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT playlist_items_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX playlist_items_song_id_idx ON playlist_items (song_id);
+
+-- +goose Down
+DROP TABLE playlist_items;
+DROP TABLE playlists;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- До появления ролей любой вошедший пользователь мог выполнять любые действия,
+-- поэтому существующие пользователи становятся администраторами, а новые по умолчанию - читателями
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'admin'
+        CONSTRAINT users_role_check CHECK (role IN ('viewer', 'editor', 'admin'));
+
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE favorites (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX idx_favorites_song_id ON favorites (song_id);
+
+CREATE TABLE song_ratings (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    rated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX idx_song_ratings_song_id ON song_ratings (song_id);
+
+CREATE TABLE plays (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    played_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_plays_user_id ON plays (user_id, played_at DESC);
+CREATE INDEX idx_plays_song_id ON plays (song_id);
+
+-- +goose Down
+DROP TABLE plays;
+DROP TABLE song_ratings;
+DROP TABLE favorites;
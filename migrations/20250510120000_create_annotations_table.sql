@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE annotations (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    parent_id INT REFERENCES annotations (id) ON DELETE CASCADE,
+    verse_number INT,
+    start_offset INT,
+    end_offset INT,
+    quote TEXT,
+    orphaned BOOLEAN NOT NULL DEFAULT false,
+    body TEXT NOT NULL,
+    author TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- Аннотация привязана к диапазону символов куплета, ответ наследует привязку аннотации
+    CHECK (
+        (parent_id IS NULL AND verse_number IS NOT NULL AND start_offset >= 0 AND end_offset > start_offset AND quote IS NOT NULL)
+        OR (parent_id IS NOT NULL AND verse_number IS NULL AND start_offset IS NULL AND end_offset IS NULL AND quote IS NULL)
+    )
+);
+
+CREATE INDEX idx_annotations_song_id ON annotations (song_id);
+CREATE INDEX idx_annotations_parent_id ON annotations (parent_id);
+
+-- +goose Down
+DROP TABLE annotations;
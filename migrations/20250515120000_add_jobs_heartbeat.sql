@@ -0,0 +1,12 @@
+-- +goose Up
+-- Обработчик периодически обновляет heartbeat_at выполняющейся задачи. Задача, отметка которой
+-- устарела, осталась от остановившегося обработчика и возвращается в очередь.
+ALTER TABLE jobs ADD COLUMN heartbeat_at TIMESTAMPTZ;
+
+UPDATE jobs SET heartbeat_at = started_at WHERE status = 'running';
+
+CREATE INDEX jobs_running_heartbeat_idx ON jobs (heartbeat_at) WHERE status = 'running';
+
+-- +goose Down
+DROP INDEX jobs_running_heartbeat_idx;
+ALTER TABLE jobs DROP COLUMN heartbeat_at;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Индекс для периодического удаления устаревших записей кэша
+CREATE INDEX details_cache_expires_at_idx ON details_cache (expires_at);
+
+-- +goose Down
+DROP INDEX details_cache_expires_at_idx;
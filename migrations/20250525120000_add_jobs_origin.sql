@@ -0,0 +1,19 @@
+-- +goose Up
+-- Инициатор и сведения о запросе, поставившем задачу, нужны только журналу аудита и хранятся
+-- отдельно от параметров задачи: параметры возвращаются в ответе GET /jobs/{id}.
+ALTER TABLE jobs ADD COLUMN actor TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+
+UPDATE jobs SET
+    actor = COALESCE(payload->>'actor', ''),
+    request_id = COALESCE(payload->>'request_id', ''),
+    ip = COALESCE(payload->>'ip', ''),
+    payload = payload - 'actor' - 'request_id' - 'ip';
+
+-- +goose Down
+UPDATE jobs SET payload = payload || jsonb_build_object('actor', actor, 'request_id', request_id, 'ip', ip);
+
+ALTER TABLE jobs DROP COLUMN ip;
+ALTER TABLE jobs DROP COLUMN request_id;
+ALTER TABLE jobs DROP COLUMN actor;
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"music_library/internal/importer"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"os"
+)
+
+// runScan выполняет команду сканирования каталога с аудиофайлами:
+//
+//	music_library scan [-dry-run] [-overwrite] <каталог>
+//
+// Исполнитель, название, дата и текст песни читаются из тегов ID3v2 (MP3) и комментариев Vorbis (FLAC).
+// Новые песни добавляются, у существующих заполняются пустые поля. Если теги расходятся с заполненными
+// полями песни, трек считается конфликтующим и песня не изменяется, -overwrite заменяет такие поля.
+// Итог выводится в stdout в формате JSON.
+func runScan(musicService service.MusicService, args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "показать результат без сохранения")
+	overwrite := fs.Bool("overwrite", false, "заменять заполненные поля песен данными из тегов")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "использование: music_library scan [-dry-run] [-overwrite] <каталог>")
+		return 2
+	}
+
+	rows, err := importer.NewAudioReader(fs.Arg(0))
+	if err != nil {
+		log.Printf("Ошибка сканирования каталога: %v", err)
+		return 1
+	}
+
+	opts := models.ImportOptions{DryRun: *dryRun, KeepExisting: !*overwrite}
+	report, err := musicService.ImportSongs(context.Background(), rows, opts)
+	return printImportReport(report, err)
+}
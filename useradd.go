@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"music_library/internal/models"
+	"music_library/internal/service"
+	"os"
+	"strings"
+)
+
+// runUserAdd выполняет команду регистрации пользователя:
+//
+//	music_library useradd [-role viewer|editor|admin] <имя>
+//
+// Пароль читается из первой строки stdin, чтобы он не попадал в историю команд и список процессов.
+func runUserAdd(authService service.AuthService, args []string) int {
+	fs := flag.NewFlagSet("useradd", flag.ContinueOnError)
+	role := fs.String("role", models.RoleViewer, "роль пользователя: viewer, editor или admin")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "использование: music_library useradd [-role viewer|editor|admin] <имя>, пароль передается в stdin")
+		return 2
+	}
+	username := fs.Arg(0)
+
+	fmt.Fprint(os.Stderr, "Пароль: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		log.Printf("Ошибка чтения пароля: %v", err)
+		return 1
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	req := models.UserRequest{Username: username, Password: password, Role: *role}
+	id, err := authService.CreateUser(context.Background(), req)
+	if err != nil {
+		log.Printf("Ошибка регистрации пользователя: %v", err)
+		return 1
+	}
+
+	fmt.Printf("Пользователь %s (%s) зарегистрирован, ID: %d\n", username, *role, id)
+	return 0
+}